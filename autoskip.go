@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// autoSkipRule 描述 -auto-skip-dir-if-matches 的一条规则：
+// 目录中直接包含的、名称匹配 glob 的文件多于 count 个时跳过整个目录
+type autoSkipRule struct {
+	re    *regexp.Regexp
+	count int
+}
+
+// parseAutoSkipRule 解析 "glob:count" 形式的规则，如 "*.json:100"
+func parseAutoSkipRule(s string) (autoSkipRule, error) {
+	glob, count, err := splitGlobCount(s)
+	if err != nil {
+		return autoSkipRule{}, err
+	}
+	re, err := regexp.Compile("^" + globToRegexp(glob) + "$")
+	if err != nil {
+		return autoSkipRule{}, fmt.Errorf("invalid glob in rule %q: %w", s, err)
+	}
+	return autoSkipRule{re: re, count: count}, nil
+}
+
+// autoSkipDir 判断目录是否因某条规则而被整体跳过
+func autoSkipDir(path string, rules []autoSkipRule) (bool, error) {
+	if len(rules) == 0 {
+		return false, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return false, err
+	}
+	for _, rule := range rules {
+		n := 0
+		for _, e := range entries {
+			if !e.IsDir() && rule.re.MatchString(e.Name()) {
+				n++
+			}
+		}
+		if n > rule.count {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestAutoSkipDir tests skipping directories with more matching files than the threshold.
+func TestAutoSkipDir(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"main.go":               "package main",
+		"testdata/small/a.json": "{}",
+		"testdata/small/b.json": "{}",
+	}
+	for i := 0; i < 20; i++ {
+		files[filepath.Join("testdata", "fixtures", fmt.Sprintf("case%02d.json", i))] = "{}"
+	}
+	files[filepath.Join("testdata", "fixtures", "README.md")] = "fixtures"
+	writeTestFiles(t, tempDir, files)
+
+	rule, err := parseAutoSkipRule("*.json:10")
+	if err != nil {
+		t.Fatalf("parseAutoSkipRule() returned error: %v", err)
+	}
+	opts := Options{ExcludeList: map[string]bool{}, AutoSkipDirs: []autoSkipRule{rule}, Excluded: &exclusionLog{}}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	for _, path := range []string{"main.go", "testdata/small/a.json", "testdata/small/b.json"} {
+		if _, ok := fileContents[filepath.FromSlash(path)]; !ok {
+			t.Errorf("Expected file not found: %s", path)
+		}
+	}
+	if _, ok := fileContents[filepath.Join("testdata", "fixtures", "README.md")]; ok {
+		t.Error("Files in an auto-skipped directory should be excluded")
+	}
+	if containsLine(tree, "fixtures/") {
+		t.Errorf("Auto-skipped directory should be pruned from the tree:\n%s", tree)
+	}
+	if len(opts.Excluded.entries) != 1 || opts.Excluded.entries[0] != (exclusion{Path: "testdata/fixtures/", Reason: reasonAutoSkip}) {
+		t.Errorf("Excluded entries = %v, want the fixtures directory", opts.Excluded.entries)
+	}
+}
+
+// TestParseAutoSkipRule tests rule parsing errors.
+func TestParseAutoSkipRule(t *testing.T) {
+	for _, s := range []string{"*.json", ":10", "*.json:many", "*.json:-1"} {
+		if _, err := parseAutoSkipRule(s); err == nil {
+			t.Errorf("parseAutoSkipRule(%q) should fail", s)
+		}
+	}
+}
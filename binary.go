@@ -0,0 +1,26 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+)
+
+// binarySniffLen 是判断二进制文件时检查的前缀长度
+const binarySniffLen = 512
+
+// isBinary 通过检查开头是否含有 NUL 字节判断文件是否为二进制
+func isBinary(content []byte) bool {
+	if len(content) > binarySniffLen {
+		content = content[:binarySniffLen]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// hexDump 以 hexdump -C 的格式渲染二进制内容，超过 maxSize 时只给出说明
+func hexDump(content []byte, maxSize int) string {
+	if maxSize > 0 && len(content) > maxSize {
+		return fmt.Sprintf("[binary file, %d bytes, exceeds hex dump limit of %d bytes]\n", len(content), maxSize)
+	}
+	return hex.Dump(content)
+}
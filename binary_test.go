@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestIsBinary tests NUL-byte based binary detection.
+func TestIsBinary(t *testing.T) {
+	if isBinary([]byte("plain text\n")) {
+		t.Error("Text should not be detected as binary")
+	}
+	if !isBinary([]byte{0x7f, 'E', 'L', 'F', 0x00}) {
+		t.Error("Content with NUL bytes should be detected as binary")
+	}
+}
+
+// TestBinaryAsHex tests the hexdump -C style rendering of a small binary fixture.
+func TestBinaryAsHex(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	fixture := []byte("Hello\x00\x01\x02binary\xff")
+	if err := os.WriteFile(filepath.Join(tempDir, "blob.bin"), fixture, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "big.bin"), make([]byte, 64), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	opts := Options{ExcludeList: map[string]bool{}, BinaryAsHex: true, BinaryHexMaxSize: 32}
+	_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	want := "00000000  48 65 6c 6c 6f 00 01 02  62 69 6e 61 72 79 ff     |Hello...binary.|\n"
+	if got := fileContents["blob.bin"]; got != want {
+		t.Errorf("Hex dump = %q, want %q", got, want)
+	}
+	if got := fileContents["big.bin"]; got != "[binary file, 64 bytes, exceeds hex dump limit of 32 bytes]\n" {
+		t.Errorf("Oversized binary = %q", got)
+	}
+}
+
+// TestSkipBinary tests that binary files are skipped by default but kept in the tree.
+func TestSkipBinary(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":         "package main",
+		"assets/logo.png": "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, Excluded: &exclusionLog{}}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if _, ok := fileContents[filepath.FromSlash("assets/logo.png")]; ok {
+		t.Error("Binary file content should be skipped")
+	}
+	if _, ok := fileContents["main.go"]; !ok {
+		t.Error("Expected file not found: main.go")
+	}
+	if !containsLine(tree, "logo.png (binary, skipped)") {
+		t.Errorf("Binary file should be annotated in the tree:\n%s", tree)
+	}
+	if len(opts.Excluded.entries) != 1 || opts.Excluded.entries[0].Reason != reasonBinary {
+		t.Errorf("Unexpected exclusions: %v", opts.Excluded.entries)
+	}
+
+	// -include-binary opts back in to the raw content.
+	opts = Options{ExcludeList: map[string]bool{}, IncludeBinary: true}
+	tree, fileContents, err = buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if got := fileContents[filepath.FromSlash("assets/logo.png")]; got != "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR" {
+		t.Errorf("Binary content = %q", got)
+	}
+	if !containsLine(tree, "logo.png") {
+		t.Errorf("Binary file should be listed without annotation:\n%s", tree)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigName 是仓库根目录下默认读取的配置文件
+const defaultConfigName = ".gitingest.yaml"
+
+// loadConfigFile 读取 YAML 配置文件，把其中的选项作为默认值应用到 fs。键为 flag 名称，值的规则与
+// -options-stdin 相同，已经设置过的 flag 优先。path 为空时读取 rootDir 下的 .gitingest.yaml，
+// 该文件不存在时不做任何事；用 -config 显式指定的文件必须存在。
+func loadConfigFile(fs *flag.FlagSet, rootDir, path string) error {
+	explicit := path != ""
+	if !explicit {
+		path = filepath.Join(rootDir, defaultConfigName)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("decoding %s: %w", path, err)
+	}
+	if err := applyOptionValues(fs, values); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// templateSkipFlags 是不写入配置模板的选项：它们只在命令行上有意义
+var templateSkipFlags = map[string]bool{"config": true, "options-stdin": true, "init": true, "force": true, "version": true}
+
+// writeConfigTemplate 将 fs 中的选项写成 YAML 配置模板：每个选项先以注释给出说明，再给出被注释掉的
+// "名称: 默认值" 一行，去掉行首的 "# " 即可启用
+func writeConfigTemplate(w io.Writer, fs *flag.FlagSet) error {
+	fmt.Fprintf(w, "# local-gitingest configuration, read from %s in the repository root.\n", defaultConfigName)
+	fmt.Fprintln(w, "# Every option is listed with its default; uncomment a line to change it.")
+	fmt.Fprintln(w, "# Command-line flags and -options-stdin take precedence over this file.")
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if templateSkipFlags[f.Name] || err != nil {
+			return
+		}
+		_, err = fmt.Fprintf(w, "\n# %s\n# %s: %s\n", strings.ReplaceAll(f.Usage, "\n", " "), f.Name, templateValue(f))
+	})
+	return err
+}
+
+// templateValue 返回选项默认值在 YAML 中的写法：字符串加引号，可重复的选项为空列表
+func templateValue(f *flag.Flag) string {
+	if _, ok := f.Value.(*stringList); ok {
+		return "[]"
+	}
+	if g, ok := f.Value.(flag.Getter); ok {
+		switch g.Get().(type) {
+		case bool, int, int64, uint, uint64, float64:
+			return f.DefValue
+		}
+	} else if _, ok := f.Value.(*byteSize); ok {
+		return f.DefValue
+	}
+	return strconv.Quote(f.DefValue)
+}
+
+// initConfigFile 在 path 写入配置模板，文件已存在且未指定 force 时返回错误
+func initConfigFile(path string, fs *flag.FlagSet, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists (use -force to overwrite it)", path)
+	}
+	if err != nil {
+		return err
+	}
+	if err := writeConfigTemplate(f, fs); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestConfigFile tests loading defaults from .gitingest.yaml, with command-line flags taking precedence.
+func TestConfigFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	newFlagSet := func(args ...string) (*flag.FlagSet, *string, *string, *bool, *int64, *stringList) {
+		var (
+			exclude, format string
+			sizeLimit       bool
+			maxSize         int64
+			patterns        stringList
+		)
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.StringVar(&exclude, "exclude", "", "")
+		fs.StringVar(&format, "format", "txt", "")
+		fs.BoolVar(&sizeLimit, "size-limit", false, "")
+		fs.Int64Var(&maxSize, "max-size", 50*1024, "")
+		fs.Var(&patterns, "gitignore-pattern", "")
+		if err := fs.Parse(args); err != nil {
+			t.Fatalf("Parse() returned error: %v", err)
+		}
+		return fs, &exclude, &format, &sizeLimit, &maxSize, &patterns
+	}
+
+	// Without a config file nothing changes.
+	fs, _, format, _, _, _ := newFlagSet()
+	if err := loadConfigFile(fs, tempDir, ""); err != nil {
+		t.Fatalf("loadConfigFile() without a config returned error: %v", err)
+	}
+	if *format != "txt" {
+		t.Errorf("format = %q, want the flag default", *format)
+	}
+
+	writeTestFiles(t, tempDir, map[string]string{
+		defaultConfigName: "# defaults\nexclude: .log,.tmp\nformat: md\nsize-limit: true\nmax-size: 2048\ngitignore-pattern:\n  - build/\n  - '*.pb.go'\n",
+		"alt.yaml":        "format: json\n",
+	})
+
+	fs, exclude, format, sizeLimit, maxSize, patterns := newFlagSet("-format", "yaml")
+	if err := loadConfigFile(fs, tempDir, ""); err != nil {
+		t.Fatalf("loadConfigFile() returned error: %v", err)
+	}
+	if *exclude != ".log,.tmp" || !*sizeLimit || *maxSize != 2048 {
+		t.Errorf("exclude = %q, size-limit = %v, max-size = %d; want values from the config", *exclude, *sizeLimit, *maxSize)
+	}
+	if *format != "yaml" {
+		t.Errorf("format = %q, want yaml from the command line", *format)
+	}
+	if strings.Join(*patterns, " ") != "build/ *.pb.go" {
+		t.Errorf("gitignore-pattern = %v, want both config entries", *patterns)
+	}
+
+	// -config points at an alternate file.
+	fs, _, format, _, _, _ = newFlagSet()
+	if err := loadConfigFile(fs, tempDir, filepath.Join(tempDir, "alt.yaml")); err != nil {
+		t.Fatalf("loadConfigFile() returned error: %v", err)
+	}
+	if *format != "json" {
+		t.Errorf("format = %q, want json from the alternate config", *format)
+	}
+
+	// An explicitly named file must exist, and must be valid.
+	fs, _, _, _, _, _ = newFlagSet()
+	if err := loadConfigFile(fs, tempDir, filepath.Join(tempDir, "missing.yaml")); err == nil {
+		t.Error("loadConfigFile() with a missing -config file should fail")
+	}
+	writeTestFiles(t, tempDir, map[string]string{"bad.yaml": "no-such-flag: 1\n"})
+	if err := loadConfigFile(fs, tempDir, filepath.Join(tempDir, "bad.yaml")); err == nil {
+		t.Error("loadConfigFile() with an unknown option should fail")
+	}
+}
+
+// TestInitConfigFile tests that the -init template parses as a config, also with every option uncommented.
+func TestInitConfigFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	newFlagSet := func() (*flag.FlagSet, *string, *bool, *byteSize, *stringList) {
+		var (
+			exclude   string
+			sizeLimit bool
+			maxSize   = byteSize(50 * 1024)
+			patterns  stringList
+			olderThan time.Duration
+			ratio     float64
+		)
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.StringVar(&exclude, "exclude", ".log,.tmp", "Comma-separated extensions")
+		fs.BoolVar(&sizeLimit, "size-limit", false, "Enable the size limit")
+		fs.Var(&maxSize, "max-size", "Maximum file `size`")
+		fs.Var(&patterns, "gitignore-pattern", "Extra pattern (repeatable)")
+		fs.DurationVar(&olderThan, "older-than", 0, "Age limit")
+		fs.Float64Var(&ratio, "data-file-ratio", 1.5, "Ratio")
+		fs.Bool("version", false, "Not a config option")
+		return fs, &exclude, &sizeLimit, &maxSize, &patterns
+	}
+
+	path := filepath.Join(tempDir, defaultConfigName)
+	fs, _, _, _, _ := newFlagSet()
+	if err := initConfigFile(path, fs, false); err != nil {
+		t.Fatalf("initConfigFile() returned error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read template: %v", err)
+	}
+	if !strings.Contains(string(data), "# Enable the size limit\n# size-limit: false\n") || strings.Contains(string(data), "version") {
+		t.Errorf("Unexpected template:\n%s", data)
+	}
+	if err := loadConfigFile(fs, tempDir, ""); err != nil {
+		t.Errorf("loadConfigFile() on the template returned error: %v", err)
+	}
+
+	// Uncommenting every option keeps the defaults.
+	uncommented := regexp.MustCompile(`(?m)^# ([a-z][a-z0-9-]*: )`).ReplaceAllString(string(data), "$1")
+	if err := os.WriteFile(path, []byte(uncommented), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	fs, exclude, sizeLimit, maxSize, patterns := newFlagSet()
+	if err := loadConfigFile(fs, tempDir, ""); err != nil {
+		t.Fatalf("loadConfigFile() on the uncommented template returned error: %v\n%s", err, uncommented)
+	}
+	if *exclude != ".log,.tmp" || *sizeLimit || *maxSize != 50*1024 || len(*patterns) != 0 {
+		t.Errorf("Defaults changed: exclude = %q, size-limit = %v, max-size = %d, patterns = %v", *exclude, *sizeLimit, *maxSize, *patterns)
+	}
+
+	// An existing config is only replaced with force.
+	if err := initConfigFile(path, fs, false); err == nil {
+		t.Error("initConfigFile() should refuse to overwrite an existing file")
+	}
+	if err := initConfigFile(path, fs, true); err != nil {
+		t.Errorf("initConfigFile() with force returned error: %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import "unicode"
+
+// dataFileMinChars 是 -skip-data-files 判断时要求的最少非空白字符数，过短的文件不做判断
+const dataFileMinChars = 256
+
+// isDataFile 判断文本是否像数据转储（如 CSV、数值 JSON）：数字和标点符号等非字母字符
+// 与字母的数量之比达到 ratio 时返回 true，空白字符不计入
+func isDataFile(text string, ratio float64) bool {
+	letters, others := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+		case unicode.IsLetter(r):
+			letters++
+		default:
+			others++
+		}
+	}
+	if letters+others < dataFileMinChars {
+		return false
+	}
+	return float64(others) >= ratio*float64(letters)
+}
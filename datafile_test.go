@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestSkipDataFiles tests that CSV-like data dumps are skipped and reported with -skip-data-files.
+func TestSkipDataFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	code := "package main\n\n// main prints a greeting and the sum of a few numbers.\nfunc main() {\n" +
+		strings.Repeat("\tfmt.Println(\"hello, world\", add(1, 2))\n", 10) + "}\n"
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":        code,
+		"data/rates.csv": "id,rate,amount\n" + strings.Repeat("1024,0.375,-12.50\n", 50),
+		"small.csv":      "1,2,3\n",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, SkipDataFiles: true, DataFileRatio: 1.5, Excluded: &exclusionLog{}}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if _, ok := fileContents["data/rates.csv"]; ok {
+		t.Error("CSV data dump should be skipped")
+	}
+	if containsLine(tree, "rates.csv") {
+		t.Errorf("Skipped data file should not be listed in the tree:\n%s", tree)
+	}
+	if fileContents["main.go"] != code {
+		t.Error("Source code should be kept")
+	}
+	if _, ok := fileContents["small.csv"]; !ok {
+		t.Error("Files too short to judge should be kept")
+	}
+	if len(opts.Excluded.entries) != 1 || opts.Excluded.entries[0] != (exclusion{Path: "data/rates.csv", Reason: reasonData}) {
+		t.Errorf("Excluded entries = %v, want the CSV file as data", opts.Excluded.entries)
+	}
+}
@@ -0,0 +1,13 @@
+package main
+
+import "bytes"
+
+// deniedMarker 返回内容中出现的第一个 -deny-content 标记，没有时返回空字符串
+func deniedMarker(content []byte, markers []string) string {
+	for _, m := range markers {
+		if bytes.Contains(content, []byte(m)) {
+			return m
+		}
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDenyContent tests that files containing a deny-listed marker are skipped and reported with the marker.
+func TestDenyContent(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":         "package main",
+		"docs/roadmap.md": "# Roadmap\n\nCONFIDENTIAL - do not distribute\n",
+		"notes.txt":       "confidential in lower case is not a banner",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, DenyContent: []string{"INTERNAL ONLY", "CONFIDENTIAL"}, Excluded: &exclusionLog{}}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if _, ok := fileContents[filepath.Join("docs", "roadmap.md")]; ok {
+		t.Error("File with a banner should be skipped")
+	}
+	if containsLine(tree, "roadmap.md") {
+		t.Errorf("Skipped file should not be listed in the tree:\n%s", tree)
+	}
+	if len(fileContents) != 2 {
+		t.Errorf("main.go and notes.txt should be kept, got %v", fileContents)
+	}
+
+	var report strings.Builder
+	opts.Excluded.write(&report)
+	if want := "docs/roadmap.md\tdeny-content\tcontains \"CONFIDENTIAL\"\n"; report.String() != want {
+		t.Errorf("Report = %q, want %q", report.String(), want)
+	}
+}
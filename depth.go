@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// depthRule 描述 -depth-glob 的一条规则：在匹配 root 的目录之下最多深入 depth 层
+type depthRule struct {
+	root  *regexp.Regexp
+	depth int
+}
+
+// parseDepthRule 解析 "glob:N" 形式的规则。glob 末尾的 /** 表示该目录之下的子树，
+// 如 "third_party/**:1" 只收录 third_party 的直接子条目。
+func parseDepthRule(s string) (depthRule, error) {
+	glob, depth, err := splitGlobCount(s)
+	if err != nil {
+		return depthRule{}, err
+	}
+	glob = strings.TrimSuffix(glob, "/**")
+	if glob == "" || glob == "**" {
+		return depthRule{}, fmt.Errorf("invalid glob in rule %q: the glob must name a directory", s)
+	}
+	re, err := compileGlob(glob)
+	if err != nil {
+		return depthRule{}, fmt.Errorf("invalid glob in rule %q: %w", s, err)
+	}
+	return depthRule{root: re, depth: depth}, nil
+}
+
+// pathParts 将相对路径拆分为各级名称，len 即路径的深度。路径先经过 filepath.Clean 并统一为 / 分隔，
+// 使多余的分隔符、"./" 前缀以及 Windows 上混用的分隔符不影响结果；根目录 "." 的深度为 0。
+func pathParts(relPath string) []string {
+	p := filepath.ToSlash(filepath.Clean(relPath))
+	if p == "." || p == "/" {
+		return nil
+	}
+	return strings.Split(strings.TrimPrefix(p, "/"), "/")
+}
+
+// depthExceeded 判断相对路径是否位于某条规则的根目录之下超过其深度限制的位置
+func depthExceeded(relPath string, rules []depthRule) bool {
+	if len(rules) == 0 {
+		return false
+	}
+	parts := pathParts(relPath)
+	for i := 1; i < len(parts); i++ {
+		ancestor := strings.Join(parts[:i], "/")
+		for _, rule := range rules {
+			if len(parts)-i > rule.depth && rule.root.MatchString(ancestor) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDepthGlob tests that only subtrees matching a -depth-glob rule are depth-limited.
+func TestDepthGlob(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"third_party/LICENSE.txt":        "license",
+		"third_party/lib/lib.go":         "package lib",
+		"third_party/lib/deep/deep.go":   "package deep",
+		"src/a/b/c/deep.go":              "package c",
+		"examples/one/main.go":           "package main",
+		"examples/one/sub/util.go":       "package sub",
+		"examples/one/sub/more/extra.go": "package more",
+	})
+
+	var rules []depthRule
+	for _, s := range []string{"third_party/**:1", "examples/*:2"} {
+		rule, err := parseDepthRule(s)
+		if err != nil {
+			t.Fatalf("parseDepthRule(%q) returned error: %v", s, err)
+		}
+		rules = append(rules, rule)
+	}
+	opts := Options{ExcludeList: map[string]bool{}, DepthRules: rules}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	for _, path := range []string{"third_party/LICENSE.txt", "src/a/b/c/deep.go", "examples/one/main.go", "examples/one/sub/util.go"} {
+		if _, ok := fileContents[filepath.FromSlash(path)]; !ok {
+			t.Errorf("Expected file not found: %s", path)
+		}
+	}
+	for _, path := range []string{"third_party/lib/lib.go", "third_party/lib/deep/deep.go", "examples/one/sub/more/extra.go"} {
+		if _, ok := fileContents[filepath.FromSlash(path)]; ok {
+			t.Errorf("File beyond the depth limit should be skipped: %s", path)
+		}
+	}
+	if !containsLine(tree, "lib/") || containsLine(tree, "deep/") || containsLine(tree, "extra.go") {
+		t.Errorf("Tree should list entries up to the limit only:\n%s", tree)
+	}
+	// Directories whose children were cut by the limit end with the truncation marker.
+	for _, want := range []string{"    └── lib/\n        └── ⋯ [truncated]\n", "│           ├── more/\n│           │   └── ⋯ [truncated]\n"} {
+		if !strings.Contains(tree, want) {
+			t.Errorf("Tree is missing %q:\n%s", want, tree)
+		}
+	}
+	if strings.Count(tree, "[truncated]") != 2 {
+		t.Errorf("Only lib/ and more/ should be marked as truncated:\n%s", tree)
+	}
+
+	opts.TruncationMarker = "<cut>"
+	if tree, _, err = buildDirectoryStructure(tempDir, opts); err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if strings.Count(tree, "── <cut>\n") != 2 || strings.Contains(tree, "[truncated]") {
+		t.Errorf("Custom truncation marker was not used:\n%s", tree)
+	}
+}
+
+// TestPathParts tests that path depth ignores redundant separators and treats the root as depth 0.
+func TestPathParts(t *testing.T) {
+	tests := map[string]int{
+		".":                          0,
+		"":                           0,
+		"a":                          1,
+		"./a/":                       1,
+		"a//b":                       2,
+		filepath.Join("a", "b", "c"): 3,
+		"a/b/c/d.go":                 4,
+	}
+	for path, want := range tests {
+		if got := len(pathParts(path)); got != want {
+			t.Errorf("depth of %q = %d, want %d", path, got, want)
+		}
+	}
+}
+
+// TestNestedTreeIndentation tests that each level of a three-level nesting is indented by one step.
+func TestNestedTreeIndentation(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{"one/two/three/leaf.go": "package three"})
+
+	tree, _, err := buildDirectoryStructure(tempDir, Options{ExcludeList: map[string]bool{}})
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	want := filepath.Base(tempDir) + "/\n" +
+		"└── one/\n" +
+		"    └── two/\n" +
+		"        └── three/\n" +
+		"            └── leaf.go\n"
+	if tree != want {
+		t.Errorf("Tree =\n%s\nwant:\n%s", tree, want)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// isDocAnchor 判断文件名是否为 -doc-files 中列出的目录级文档，不区分大小写
+func isDocAnchor(relPath string, docFiles []string) bool {
+	name := filepath.Base(relPath)
+	for _, doc := range docFiles {
+		if strings.EqualFold(name, doc) {
+			return true
+		}
+	}
+	return false
+}
+
+// orderWithDocAnchors 调整输出顺序，使每个目录的文档出现在该目录（含子目录）的第一个文件之前，
+// 其余文件保持原有顺序
+func orderWithDocAnchors(paths []string, docFiles []string) []string {
+	anchors := make(map[string][]string) // 目录 -> 文档路径
+	var rest []string
+	for _, p := range paths {
+		if isDocAnchor(p, docFiles) {
+			dir := filepath.Dir(p)
+			anchors[dir] = append(anchors[dir], p)
+		} else {
+			rest = append(rest, p)
+		}
+	}
+
+	ordered := make([]string, 0, len(paths))
+	emit := func(dir string) {
+		ordered = append(ordered, anchors[dir]...)
+		delete(anchors, dir)
+	}
+	for _, p := range rest {
+		// 从根目录到文件所在目录，依次放入尚未输出的文档
+		emit(".")
+		dir := ""
+		for _, part := range strings.Split(filepath.Dir(p), string(filepath.Separator)) {
+			if part == "." {
+				break
+			}
+			dir = filepath.Join(dir, part)
+			emit(dir)
+		}
+		ordered = append(ordered, p)
+	}
+	// 只有文档而没有其他文件的目录
+	for _, p := range paths {
+		if _, ok := anchors[filepath.Dir(p)]; ok {
+			emit(filepath.Dir(p))
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDocAnchors tests that a directory's ARCHITECTURE.md is written before that directory's files.
+func TestDocAnchors(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":                   "package main",
+		"api/AAA_generated.go":      "package api",
+		"api/handler.go":            "package api",
+		"api/ARCHITECTURE.md":       "# API design",
+		"store/db/ARCHITECTURE.md":  "# Storage design",
+		"store/cache.go":            "package store",
+		"store/db/sql.go":           "package db",
+		"store/db/zz_migrations.go": "package db",
+	})
+
+	// .md files are excluded by the filters, the anchors are included anyway.
+	opts := Options{ExcludeList: map[string]bool{".md": true}, DocAnchors: []string{"ARCHITECTURE.md"}, ShowBanners: true}
+	var out bytes.Buffer
+	if err := writeDirectoryStructure(tempDir, opts, &out); err != nil {
+		t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+	}
+
+	var order []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.HasPrefix(line, "File: ") {
+			order = append(order, filepath.ToSlash(strings.TrimPrefix(line, "File: ")))
+		}
+	}
+	want := []string{
+		"api/ARCHITECTURE.md",
+		"api/AAA_generated.go",
+		"api/handler.go",
+		"main.go",
+		"store/cache.go",
+		"store/db/ARCHITECTURE.md",
+		"store/db/sql.go",
+		"store/db/zz_migrations.go",
+	}
+	if strings.Join(order, " ") != strings.Join(want, " ") {
+		t.Errorf("File order = %v, want %v", order, want)
+	}
+}
+
+// TestDocAnchorsExclusions tests that anchors still respect .gitignore and -deny-content and appear in the tree.
+func TestDocAnchorsExclusions(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		".gitignore":            "sub/CONTRIBUTING.md\n",
+		"main.go":               "package main",
+		"ARCHITECTURE.md":       "# Design",
+		"sub/sub.go":            "package sub",
+		"sub/ARCHITECTURE.md":   "# CONFIDENTIAL design",
+		"sub/CONTRIBUTING.md":   "# Ignored",
+		"other/CONTRIBUTING.md": "# Contributing",
+	})
+
+	opts := Options{
+		ExcludeList:  map[string]bool{".md": true},
+		UseGitignore: true,
+		DocAnchors:   []string{"CONTRIBUTING.md", "ARCHITECTURE.md"},
+		DenyContent:  []string{"CONFIDENTIAL"},
+		ShowTree:     true,
+		ShowBanners:  true,
+	}
+	var out bytes.Buffer
+	if err := writeDirectoryStructure(tempDir, opts, &out); err != nil {
+		t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+	}
+	output := out.String()
+
+	for _, name := range []string{"ARCHITECTURE.md", "other/CONTRIBUTING.md"} {
+		if !strings.Contains(output, "File: "+filepath.FromSlash(name)+"\n") {
+			t.Errorf("Anchor %s should be included, got:\n%s", name, output)
+		}
+	}
+	if !containsLine(output, "CONTRIBUTING.md") {
+		t.Errorf("Included anchors should appear in the tree, got:\n%s", output)
+	}
+	if strings.Contains(output, "CONFIDENTIAL") || strings.Contains(output, "# Ignored") {
+		t.Errorf("Denied and gitignored anchors should not be included, got:\n%s", output)
+	}
+	if strings.Count(output, "ARCHITECTURE.md") != 2 {
+		t.Errorf("Only the root ARCHITECTURE.md should appear, in the tree and as a file, got:\n%s", output)
+	}
+}
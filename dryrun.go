@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// dryRunFiles 按目录树顺序返回 fileContents 中将被收录的文件及其在磁盘上的大小，
+// 只出现在目录树中的条目（如二进制文件、超出预算的文件）不计入
+func dryRunFiles(n *treeNode, fileContents map[string]string) []fileSize {
+	if !n.isDir {
+		if _, ok := fileContents[n.relPath]; !ok {
+			return nil
+		}
+		return []fileSize{{filepath.ToSlash(n.relPath), int(n.size)}}
+	}
+	var files []fileSize
+	for _, child := range n.children {
+		files = append(files, dryRunFiles(child, fileContents)...)
+	}
+	return files
+}
+
+// writeDryRun 逐行输出 -dry-run 预览的文件及大小，最后一行是文件总数和总大小
+func writeDryRun(w io.Writer, files []fileSize) {
+	total := 0
+	for _, f := range files {
+		fmt.Fprintf(w, "%10d  %s\n", f.Bytes, f.Path)
+		total += f.Bytes
+	}
+	fmt.Fprintf(w, "%d files, %d bytes\n", len(files), total)
+}
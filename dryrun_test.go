@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDryRun tests that -dry-run lists the filtered selection with sizes without writing the manifest.
+func TestDryRun(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":       "package main\n",
+		"README.md":     "# readme",
+		"big/data.json": strings.Repeat("x", 100),
+	})
+
+	manifestPath := filepath.Join(tempDir, "manifest.json")
+	opts := Options{ExcludeList: map[string]bool{".md": true}, IncludeSizeLimit: true, SizeLimit: 50, DryRun: true, ManifestPath: manifestPath}
+	tree, fileContents, err := ingest(tempDir, &opts)
+	if err != nil {
+		t.Fatalf("ingest() returned error: %v", err)
+	}
+	if _, err := os.Stat(manifestPath); !os.IsNotExist(err) {
+		t.Errorf("Dry run should not write the manifest, stat error: %v", err)
+	}
+
+	var out strings.Builder
+	writeDryRun(&out, dryRunFiles(tree, fileContents))
+	want := "        13  main.go\n1 files, 13 bytes\n"
+	if out.String() != want {
+		t.Errorf("Dry run output = %q, want %q", out.String(), want)
+	}
+}
+
+// TestDryRunContentChecks tests that the preview drops the same files as a real run does after reading them.
+func TestDryRunContentChecks(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	var dump strings.Builder
+	if err := writeTreeJSON(&dump, newTree("old"), false); err != nil {
+		t.Fatalf("writeTreeJSON() returned error: %v", err)
+	}
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":        "package main\n",
+		"logo.png":       "\x89PNG\x00\x00\x00",
+		"secret.txt":     "CONFIDENTIAL\n",
+		"data.csv":       strings.Repeat("1,2,3,4\n", 50),
+		"old-tree.json":  dump.String(),
+		"notes/todo.txt": "buy milk\n",
+	})
+
+	opts := Options{
+		ExcludeList:   map[string]bool{},
+		DenyContent:   []string{"CONFIDENTIAL"},
+		SkipDataFiles: true,
+		DataFileRatio: 1.5,
+		DryRun:        true,
+	}
+	tree, fileContents, err := ingest(tempDir, &opts)
+	if err != nil {
+		t.Fatalf("ingest() returned error: %v", err)
+	}
+	var paths []string
+	for _, f := range dryRunFiles(tree, fileContents) {
+		paths = append(paths, f.Path)
+	}
+	if want := "main.go notes/todo.txt"; strings.Join(paths, " ") != want {
+		t.Errorf("Dry run files = %v, want %s", paths, want)
+	}
+}
+
+// TestDryRunBudgets tests that the preview leaves out the files -max-tokens and -max-output would drop.
+func TestDryRunBudgets(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"a.txt": strings.Repeat("a", 40),
+		"b.txt": strings.Repeat("b", 40),
+		"c.txt": strings.Repeat("c", 40),
+	})
+
+	for _, opts := range []Options{
+		{ExcludeList: map[string]bool{}, DryRun: true, MaxTokens: 15},
+		{ExcludeList: map[string]bool{}, DryRun: true, MaxOutput: 60},
+	} {
+		tree, fileContents, err := ingest(tempDir, &opts)
+		if err != nil {
+			t.Fatalf("ingest() returned error: %v", err)
+		}
+		var out strings.Builder
+		writeDryRun(&out, dryRunFiles(tree, fileContents))
+		if want := "        40  a.txt\n1 files, 40 bytes\n"; out.String() != want {
+			t.Errorf("Dry run output = %q, want %q (max-tokens %d, max-output %d)", out.String(), want, opts.MaxTokens, opts.MaxOutput)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// reachableFiles 使用 go/packages 计算从 entrypoint 包出发、位于主模块内的可达 Go 源文件。
+// maxDepth 限制沿 import 图向下的层数（0 只包含入口包本身），小于 0 表示不限制。
+// 返回的路径相对于 rootDir。
+func reachableFiles(rootDir, entrypoint string, maxDepth int) (map[string]bool, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedModule,
+		Dir:  rootDir,
+	}
+	pkgs, err := packages.Load(cfg, entrypoint)
+	if err != nil {
+		return nil, fmt.Errorf("loading entrypoint %s: %w", entrypoint, err)
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("entrypoint %s matched no packages", entrypoint)
+	}
+
+	type queued struct {
+		pkg   *packages.Package
+		depth int
+	}
+	files := make(map[string]bool)
+	seen := make(map[string]bool)
+	var queue []queued
+	for _, p := range pkgs {
+		queue = append(queue, queued{p, 0})
+		seen[p.PkgPath] = true
+	}
+
+	// 按广度优先遍历，保证每个包以最短距离计算深度
+	for len(queue) > 0 {
+		p, depth := queue[0].pkg, queue[0].depth
+		queue = queue[1:]
+		// 只收录主模块内的包，标准库和第三方依赖不在仓库中
+		if p.Module == nil || !p.Module.Main {
+			continue
+		}
+		if len(p.Errors) > 0 {
+			return nil, fmt.Errorf("loading package %s: %v", p.PkgPath, p.Errors[0])
+		}
+		for _, f := range p.GoFiles {
+			relPath, err := filepath.Rel(rootDir, f)
+			if err != nil {
+				return nil, err
+			}
+			files[relPath] = true
+		}
+		if maxDepth >= 0 && depth >= maxDepth {
+			continue
+		}
+		for _, imp := range p.Imports {
+			if !seen[imp.PkgPath] {
+				seen[imp.PkgPath] = true
+				queue = append(queue, queued{imp, depth + 1})
+			}
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestFiles creates the given files (relative path -> content) under dir.
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		fullPath := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+}
+
+// TestEntrypoint tests that only files reachable from the entrypoint are included.
+func TestEntrypoint(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	// go/packages reports resolved paths, so resolve the temp dir too (e.g. /tmp on macOS).
+	tempDir, err = filepath.EvalSymlinks(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"go.mod":           "module example.com/m\n\ngo 1.22\n",
+		"cmd/foo/main.go":  "package main\n\nimport \"example.com/m/lib\"\n\nfunc main() { lib.Hello() }\n",
+		"cmd/bar/main.go":  "package main\n\nfunc main() {}\n",
+		"lib/lib.go":       "package lib\n\nimport \"example.com/m/lib/util\"\n\nfunc Hello() { util.Help() }\n",
+		"lib/util/util.go": "package util\n\nimport \"fmt\"\n\nfunc Help() { fmt.Println() }\n",
+		"unused/unused.go": "package unused\n",
+		"README.md":        "# readme\n",
+	})
+
+	reachable, err := reachableFiles(tempDir, "./cmd/foo", -1)
+	if err != nil {
+		t.Fatalf("reachableFiles() returned error: %v", err)
+	}
+
+	opts := Options{ExcludeList: map[string]bool{}, Reachable: reachable}
+	_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	expected := []string{"cmd/foo/main.go", "lib/lib.go", "lib/util/util.go"}
+	if len(fileContents) != len(expected) {
+		t.Errorf("Got %d files, want %d: %v", len(fileContents), len(expected), fileContents)
+	}
+	for _, path := range expected {
+		if _, ok := fileContents[filepath.FromSlash(path)]; !ok {
+			t.Errorf("Expected reachable file not found: %s", path)
+		}
+	}
+}
+
+// TestFollowImportsDepth tests limiting how far into the import graph files are included.
+func TestFollowImportsDepth(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	tempDir, err = filepath.EvalSymlinks(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"go.mod":          "module example.com/m\n\ngo 1.22\n",
+		"cmd/foo/main.go": "package main\n\nimport (\n\t\"example.com/m/a\"\n\t\"example.com/m/b\"\n)\n\nfunc main() { a.A(); b.B() }\n",
+		"a/a.go":          "package a\n\nimport \"example.com/m/a/deep\"\n\nfunc A() { deep.D() }\n",
+		"b/b.go":          "package b\n\nimport \"example.com/m/a\"\n\nfunc B() { a.A() }\n",
+		"a/deep/deep.go":  "package deep\n\nfunc D() {}\n",
+	})
+
+	tests := []struct {
+		depth    int
+		expected []string
+	}{
+		{0, []string{"cmd/foo/main.go"}},
+		{1, []string{"cmd/foo/main.go", "a/a.go", "b/b.go"}},
+		{-1, []string{"cmd/foo/main.go", "a/a.go", "b/b.go", "a/deep/deep.go"}},
+	}
+	for _, tt := range tests {
+		reachable, err := reachableFiles(tempDir, "./cmd/foo", tt.depth)
+		if err != nil {
+			t.Fatalf("reachableFiles(depth=%d) returned error: %v", tt.depth, err)
+		}
+		if len(reachable) != len(tt.expected) {
+			t.Errorf("depth=%d: got %v, want %v", tt.depth, reachable, tt.expected)
+		}
+		for _, path := range tt.expected {
+			if !reachable[filepath.FromSlash(path)] {
+				t.Errorf("depth=%d: missing %s", tt.depth, path)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+// 排除原因，出现在 -print-excluded 的报告中
+const (
+	reasonGitignore  = "gitignore"
+	reasonExtension  = "extension"
+	reasonRegex      = "regex"
+	reasonGlob       = "glob"
+	reasonEntrypoint = "entrypoint"
+	reasonSize       = "size"
+	reasonOwner      = "owner"
+	reasonOlderThan  = "older-than"
+	reasonExecutable = "executable"
+	reasonAutoSkip   = "auto-skip"
+	reasonOwnOutput  = "own-output"
+	reasonDepth      = "depth"
+	reasonBinary     = "binary"
+	reasonPathLength = "path-length"
+	reasonUnreadable = "unreadable"
+	reasonData       = "data"
+	reasonDenied     = "deny-content"
+)
+
+// exclusion 记录一个被发现但未收录的路径及其原因
+type exclusion struct {
+	Path   string
+	Reason string
+	Detail string // 附加说明，如读取失败的错误信息
+}
+
+// exclusionLog 收集遍历过程中被排除的路径，nil 时不记录
+type exclusionLog struct {
+	entries []exclusion
+}
+
+// add 记录一个被排除的路径，被忽略的目录以 / 结尾
+func (l *exclusionLog) add(relPath string, isDir bool, reason string) {
+	if l == nil {
+		return
+	}
+	relPath = filepath.ToSlash(relPath)
+	if isDir {
+		relPath += "/"
+	}
+	l.entries = append(l.entries, exclusion{Path: relPath, Reason: reason})
+}
+
+// addUnreadable 记录一个因读取失败而跳过的路径及其错误
+func (l *exclusionLog) addUnreadable(relPath string, isDir bool, err error) {
+	l.addDetail(relPath, isDir, reasonUnreadable, err.Error())
+}
+
+// addDetail 记录一个被排除的路径，并附上说明
+func (l *exclusionLog) addDetail(relPath string, isDir bool, reason, detail string) {
+	if l == nil {
+		return
+	}
+	l.add(relPath, isDir, reason)
+	l.entries[len(l.entries)-1].Detail = detail
+}
+
+// write 按路径顺序逐行输出 "路径<TAB>原因"，有附加说明时再加 "<TAB>说明"
+func (l *exclusionLog) write(w io.Writer) error {
+	entries := append([]exclusion(nil), l.entries...)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	for _, e := range entries {
+		line := e.Path + "\t" + e.Reason
+		if e.Detail != "" {
+			line += "\t" + e.Detail
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
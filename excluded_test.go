@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestPrintExcluded tests that each excluded file is reported with the filter that dropped it.
+func TestPrintExcluded(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":          "package main",
+		"app.log":          "log line",
+		"big.txt":          strings.Repeat("x", 100),
+		"gen/api.pb.go":    "package gen",
+		"build/out.txt":    "artifact",
+		"pkg/mock_repo.go": "package pkg",
+	})
+
+	want := map[string]string{
+		"app.log":          reasonExtension,
+		"big.txt":          reasonSize,
+		"gen/api.pb.go":    reasonGitignore,
+		"build/":           reasonGitignore,
+		"pkg/mock_repo.go": reasonRegex,
+	}
+	if runtime.GOOS != "windows" {
+		if err := os.WriteFile(filepath.Join(tempDir, "run.sh"), []byte("#!/bin/sh"), 0755); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		want["run.sh"] = reasonExecutable
+	}
+
+	opts := Options{
+		ExcludeList:       map[string]bool{".log": true},
+		IncludeSizeLimit:  true,
+		SizeLimit:         50,
+		ExcludeRegex:      regexp.MustCompile(`(^|/)mock_`),
+		ExcludeExecutable: true,
+		Ignore:            &ignoreMatcher{},
+		Excluded:          &exclusionLog{},
+	}
+	opts.Ignore.add("", []string{"*.pb.go", "build/"})
+	if _, _, err := buildDirectoryStructure(tempDir, opts); err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	var out strings.Builder
+	if err := opts.Excluded.write(&out); err != nil {
+		t.Fatalf("write() returned error: %v", err)
+	}
+	got := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		path, reason, _ := strings.Cut(line, "\t")
+		got[path] = reason
+	}
+	for path, reason := range want {
+		if got[path] != reason {
+			t.Errorf("Reason for %s = %q, want %q\nreport:\n%s", path, got[path], reason, out.String())
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("Got %d excluded entries, want %d:\n%s", len(got), len(want), out.String())
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// gitFileMeta 是一个文件最近一次提交的作者和时间，见 -json-git-meta
+type gitFileMeta struct {
+	Author       string
+	LastModified string // 提交时间，RFC 3339 格式
+}
+
+// gitHistory 是相对路径 -> 最近一次提交
+type gitHistory map[string]gitFileMeta
+
+// gitCommitMarker 标记 git log 输出中每个提交的标题行，与文件名行区分
+const gitCommitMarker = "\x1fcommit\x1f"
+
+// gitFileMetas 用一次 git log 查出 fileContents 中每个文件最近一次提交的作者和时间。
+// git log 按时间倒序输出，每个文件取第一次出现的提交；所有文件都找到后即停止读取。
+// 未被跟踪的文件不在结果中。
+func gitFileMetas(rootDir string, fileContents map[string]string) (gitHistory, error) {
+	pending := make(map[string]string, len(fileContents)) // 以 / 分隔的路径 -> fileContents 中的键
+	for relPath := range fileContents {
+		pending[filepath.ToSlash(relPath)] = relPath
+	}
+	metas := make(gitHistory)
+	if len(pending) == 0 {
+		return metas, nil
+	}
+
+	cmd := exec.Command("git", "-c", "core.quotepath=off", "log", "--no-renames", "--name-only",
+		"--format="+gitCommitMarker+"%an\x1f%cI", "--", ".")
+	cmd.Dir = rootDir
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var current gitFileMeta
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() && len(pending) > 0 {
+		line := scanner.Text()
+		if header, ok := strings.CutPrefix(line, gitCommitMarker); ok {
+			author, date, _ := strings.Cut(header, "\x1f")
+			current = gitFileMeta{Author: author, LastModified: date}
+			continue
+		}
+		if relPath, ok := pending[line]; ok {
+			metas[relPath] = current
+			delete(pending, line)
+		}
+	}
+	if len(pending) == 0 {
+		// 剩余的历史不再需要，提前结束 git log
+		cmd.Process.Kill()
+		cmd.Wait()
+		return metas, nil
+	}
+	if err := scanner.Err(); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	return metas, nil
+}
+
+// recentCommits 返回最近 n 个提交的 "短哈希 标题"（与 git log --oneline 相同，不含引用名），最新的在前。
+// 还没有任何提交的仓库返回空列表；浅克隆只返回本地已有的提交。
+func recentCommits(rootDir string, n int) ([]string, error) {
+	head := exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD")
+	head.Dir = rootDir
+	if err := head.Run(); err != nil {
+		return nil, nil
+	}
+	cmd := exec.Command("git", "log", "--no-decorate", "--format=%h %s", "-n", strconv.Itoa(n))
+	cmd.Dir = rootDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	var commits []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line != "" {
+			commits = append(commits, line)
+		}
+	}
+	return commits, nil
+}
+
+// loadGitContext 在收录完成后按 opts 查询输出所需的 Git 历史：-json-git-meta 的逐文件提交信息
+// 和 -git-log-summary 的最近提交
+func loadGitContext(rootDir string, opts *Options, fileContents map[string]string) error {
+	var err error
+	if opts.JSONGitMeta {
+		if opts.GitMeta, err = gitFileMetas(rootDir, fileContents); err != nil {
+			return err
+		}
+	}
+	if opts.GitLogSummary > 0 {
+		if opts.RecentCommits, err = recentCommits(rootDir, opts.GitLogSummary); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestGitLogSummary tests that -git-log-summary starts the output with the latest commit subjects.
+func TestGitLogSummary(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = tempDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git("init")
+	writeTestFiles(t, tempDir, map[string]string{"main.go": "package main"})
+
+	// A repository without commits gets no summary.
+	opts := Options{ExcludeList: map[string]bool{}, GitLogSummary: 2}
+	var out bytes.Buffer
+	if err := writeDirectoryStructure(tempDir, opts, &out); err != nil {
+		t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+	}
+	if strings.Contains(out.String(), "Recent commits:") {
+		t.Errorf("Empty history should not produce a summary:\n%s", out.String())
+	}
+
+	git("add", "main.go")
+	for _, subject := range []string{"Add main", "Fix typo", "Refactor parser"} {
+		git("commit", "--allow-empty", "-m", subject)
+	}
+	out.Reset()
+	if err := writeDirectoryStructure(tempDir, opts, &out); err != nil {
+		t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if lines[0] != "Recent commits:" || !strings.HasSuffix(lines[1], " Refactor parser") ||
+		!strings.HasSuffix(lines[2], " Fix typo") || lines[3] != "" {
+		t.Errorf("Output should start with the last two commits:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "Add main") {
+		t.Errorf("Only the last two commits should be listed:\n%s", out.String())
+	}
+}
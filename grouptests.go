@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// testSource 按命名约定返回测试文件对应的同目录源文件路径，relPath 不是测试文件时返回 false。
+// 支持 foo_test.go、foo_test.py / test_foo.py、foo.test.ts / foo.spec.js 以及 FooTest.java 等形式。
+func testSource(relPath string) (string, bool) {
+	dir, name := filepath.Split(relPath)
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	switch {
+	case ext == "":
+		return "", false
+	case strings.HasSuffix(base, "_test") && base != "_test":
+		base = strings.TrimSuffix(base, "_test")
+	case ext == ".py" && strings.HasPrefix(base, "test_") && base != "test_":
+		base = strings.TrimPrefix(base, "test_")
+	case strings.HasSuffix(base, ".test") || strings.HasSuffix(base, ".spec"):
+		base = strings.TrimSuffix(base, filepath.Ext(base))
+	case (ext == ".java" || ext == ".kt") && strings.HasSuffix(base, "Test") && base != "Test":
+		base = strings.TrimSuffix(base, "Test")
+	default:
+		return "", false
+	}
+	if base == "" {
+		return "", false
+	}
+	return dir + base + ext, true
+}
+
+// groupTestsWithSources 调整输出顺序，把每个测试文件紧跟在它的源文件之后。
+// 源文件未被收录的测试文件保持原来的位置。
+func groupTestsWithSources(paths []string) []string {
+	included := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		included[p] = true
+	}
+	tests := make(map[string][]string)
+	for _, p := range paths {
+		src, ok := testSource(p)
+		if !ok || !included[src] {
+			continue
+		}
+		// 源文件本身也像测试文件时（如 foo_test_test.go）不分组，避免它们一起丢失
+		if _, nested := testSource(src); !nested {
+			tests[src] = append(tests[src], p)
+		}
+	}
+
+	grouped := make(map[string]bool)
+	for _, ts := range tests {
+		for _, p := range ts {
+			grouped[p] = true
+		}
+	}
+	ordered := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if grouped[p] {
+			continue
+		}
+		ordered = append(ordered, p)
+		ordered = append(ordered, tests[p]...)
+	}
+	return ordered
+}
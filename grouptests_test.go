@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestGroupTests tests that each test file is written right after its source file.
+func TestGroupTests(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"foo.go":           "package foo",
+		"foo_test.go":      "package foo",
+		"foo_bar.go":       "package foo",
+		"orphan_test.go":   "package foo",
+		"zzz.go":           "package foo",
+		"lib/util.go":      "package lib",
+		"lib/util_test.go": "package lib",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, GroupTests: true}
+	var out bytes.Buffer
+	if err := writeDirectoryStructure(tempDir, opts, &out); err != nil {
+		t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+	}
+
+	var order []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.HasPrefix(line, "File: ") {
+			order = append(order, strings.TrimPrefix(line, "File: "))
+		}
+	}
+	want := []string{"foo.go", "foo_test.go", "foo_bar.go", "lib/util.go", "lib/util_test.go", "orphan_test.go", "zzz.go"}
+	if strings.Join(order, " ") != strings.Join(want, " ") {
+		t.Errorf("File order = %v, want %v", order, want)
+	}
+}
+
+// TestTestSource tests pairing test files with their sources by naming convention.
+func TestTestSource(t *testing.T) {
+	tests := map[string]string{
+		"pkg/foo_test.go":    "pkg/foo.go",
+		"test_app.py":        "app.py",
+		"app_test.py":        "app.py",
+		"web/button.test.ts": "web/button.ts",
+		"web/api.spec.js":    "web/api.js",
+		"FooTest.java":       "Foo.java",
+		"foo.go":             "",
+		"_test.go":           "",
+		"Makefile":           "",
+	}
+	for path, want := range tests {
+		got, ok := testSource(path)
+		if !ok {
+			got = ""
+		}
+		if got != want {
+			t.Errorf("testSource(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
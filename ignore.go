@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ignorePattern 是一条编译后的 gitignore 规则
+type ignorePattern struct {
+	re      *regexp.Regexp
+	negate  bool   // 以 ! 开头，重新包含之前被忽略的路径
+	dirOnly bool   // 以 / 结尾，只匹配目录
+	base    string // 规则所在目录（相对仓库根目录，以 / 分隔），根目录为 ""
+}
+
+// ignoreMatcher 按 gitignore 语义判断路径是否被忽略，后出现的规则优先
+type ignoreMatcher struct {
+	patterns []ignorePattern
+}
+
+// add 添加一组 gitignore 格式的规则，base 为规则所在目录
+func (m *ignoreMatcher) add(base string, lines []string) {
+	for _, line := range lines {
+		if p, ok := parseIgnorePattern(base, line); ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+}
+
+// ignored 判断以 / 分隔的相对路径是否被忽略
+func (m *ignoreMatcher) ignored(relPath string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	ignored := false
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		sub := relPath
+		if p.base != "" {
+			if !strings.HasPrefix(relPath, p.base+"/") {
+				continue
+			}
+			sub = strings.TrimPrefix(relPath, p.base+"/")
+		}
+		if p.re.MatchString(sub) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// addIgnoreFile 读取 dir 下的忽略文件（如 .gitignore）并以 base 为规则所在目录加入，文件不存在时忽略
+func (m *ignoreMatcher) addIgnoreFile(dir, name, base string) error {
+	lines, err := readIgnoreFile(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	m.add(base, lines)
+	return nil
+}
+
+// readIgnoreFile 读取忽略文件（如 .gitignore、.dockerignore）的全部行
+func readIgnoreFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(string(data), "\n"), nil
+}
+
+// dockerignorePatterns 将 .dockerignore 规则转换为等价的 gitignore 规则。
+// .dockerignore 的规则总是相对于上下文根目录锚定，且匹配目录时不区分结尾的 /。
+func dockerignorePatterns(lines []string) []string {
+	var patterns []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		prefix := ""
+		if strings.HasPrefix(line, "!") {
+			prefix = "!"
+			line = strings.TrimSpace(line[1:])
+		}
+		line = strings.TrimPrefix(path.Clean("/"+line), "/")
+		if line == "" {
+			continue
+		}
+		patterns = append(patterns, prefix+"/"+line)
+	}
+	return patterns
+}
+
+func parseIgnorePattern(base, line string) (ignorePattern, bool) {
+	line = strings.TrimRight(line, "\r")
+	// 未转义的行尾空格会被忽略
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = strings.TrimSuffix(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+
+	p := ignorePattern{base: base}
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return ignorePattern{}, false
+	}
+
+	re, err := compileGlob(line)
+	if err != nil {
+		return ignorePattern{}, false
+	}
+	p.re = re
+	return p, true
+}
+
+// compileGlob 按 gitignore 的规则编译 glob：含有 / 的 glob 从根目录锚定，
+// 否则可匹配任意层级的名称
+func compileGlob(glob string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(glob, "/")
+	glob = strings.TrimPrefix(glob, "/")
+	expr := globToRegexp(glob)
+	if !anchored {
+		expr = "(.*/)?" + expr
+	}
+	return regexp.Compile("^" + expr + "$")
+}
+
+// matchesAny 判断以 / 分隔的路径是否匹配任意一个编译后的 glob
+func matchesAny(relPath string, globs []*regexp.Regexp) bool {
+	for _, re := range globs {
+		if re.MatchString(relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitGlobCount 拆分 "glob:N" 形式的参数，如 "*.json:100"
+func splitGlobCount(s string) (string, int, error) {
+	i := strings.LastIndex(s, ":")
+	if i <= 0 {
+		return "", 0, fmt.Errorf("invalid rule %q, want glob:N", s)
+	}
+	n, err := strconv.Atoi(s[i+1:])
+	if err != nil || n < 0 {
+		return "", 0, fmt.Errorf("invalid count in rule %q", s)
+	}
+	return s[:i], n, nil
+}
+
+// globToRegexp 将 glob 转换为正则表达式（不含 ^$）。
+// * 和 ? 不匹配 /，** 可匹配任意层级目录。
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, "\\", "\\\\") + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
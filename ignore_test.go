@@ -0,0 +1,224 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestIgnoreMatcher tests gitignore pattern semantics.
+func TestIgnoreMatcher(t *testing.T) {
+	m := &ignoreMatcher{}
+	m.add("", []string{
+		"# comment",
+		"",
+		"*.log",
+		"!keep.log",
+		"build/",
+		"/tmp",
+		"docs/**/*.draft",
+		"**/cache",
+	})
+	m.add("sub", []string{"local.txt", "/anchored.txt"})
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"app.log", false, true},
+		{"logs/app.log", false, true},
+		{"keep.log", false, false},
+		{"logs/keep.log", false, false},
+		{"build", true, true},
+		{"src/build", true, true},
+		{"build", false, false}, // Directory-only pattern does not match files.
+		{"tmp", true, true},
+		{"src/tmp", true, false}, // Anchored to the root.
+		{"docs/a/b/x.draft", false, true},
+		{"docs/x.draft", false, true},
+		{"other/x.draft", false, false},
+		{"a/b/cache", true, true},
+		{"sub/local.txt", false, true},
+		{"sub/deep/local.txt", false, true},
+		{"local.txt", false, false}, // Patterns only apply below their directory.
+		{"sub/anchored.txt", false, true},
+		{"sub/deep/anchored.txt", false, false},
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := m.ignored(tt.path, tt.isDir); got != tt.ignored {
+			t.Errorf("ignored(%q, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.ignored)
+		}
+	}
+}
+
+// TestInlineGitignorePattern tests applying an inline pattern during the walk.
+func TestInlineGitignorePattern(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":            "package main",
+		"gen/api.pb.go":      "package gen",
+		"gen/keep.go":        "package gen",
+		"testdata/fixture.g": "fixture",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, Ignore: &ignoreMatcher{}}
+	opts.Ignore.add("", []string{"*.pb.go", "testdata/"})
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	for _, path := range []string{"main.go", "gen/keep.go"} {
+		if _, ok := fileContents[filepath.FromSlash(path)]; !ok {
+			t.Errorf("Expected file not found: %s", path)
+		}
+	}
+	if _, ok := fileContents[filepath.FromSlash("gen/api.pb.go")]; ok {
+		t.Error("gen/api.pb.go should be ignored")
+	}
+	if containsLine(tree, "testdata/") {
+		t.Errorf("Ignored directory should be pruned from the tree:\n%s", tree)
+	}
+}
+
+// TestDockerignore tests that .dockerignore patterns are anchored at the root and take effect during the walk.
+func TestDockerignore(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		".dockerignore":      "# build context\nbuild\n*.md\n!README.md\n/docs/**/*.txt\n",
+		"main.go":            "package main",
+		"README.md":          "# readme",
+		"CHANGELOG.md":       "changes",
+		"pkg/notes.md":       "nested, not matched by the anchored *.md",
+		"build/out.txt":      "artifact",
+		"docs/a/b/guide.txt": "guide",
+	})
+
+	lines, err := readIgnoreFile(filepath.Join(tempDir, ".dockerignore"))
+	if err != nil {
+		t.Fatalf("readIgnoreFile() returned error: %v", err)
+	}
+	opts := Options{ExcludeList: map[string]bool{}, Ignore: &ignoreMatcher{}}
+	opts.Ignore.add("", dockerignorePatterns(lines))
+	_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	for _, path := range []string{"main.go", "README.md", "pkg/notes.md"} {
+		if _, ok := fileContents[filepath.FromSlash(path)]; !ok {
+			t.Errorf("Expected file not found: %s", path)
+		}
+	}
+	for _, path := range []string{"CHANGELOG.md", "build/out.txt", "docs/a/b/guide.txt"} {
+		if _, ok := fileContents[filepath.FromSlash(path)]; ok {
+			t.Errorf("File should be ignored by .dockerignore: %s", path)
+		}
+	}
+}
+
+// TestLayeredGitignore tests honoring the root .gitignore together with nested ones.
+func TestLayeredGitignore(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		".gitignore":           "*.log\nbuild/\n/secret.txt\n",
+		"main.go":              "package main",
+		"app.log":              "log",
+		"secret.txt":           "root secret",
+		"build/out.txt":        "artifact",
+		"pkg/.gitignore":       "!important.log\n/local.txt\ncache/\n",
+		"pkg/secret.txt":       "not anchored at the root, kept",
+		"pkg/debug.log":        "log",
+		"pkg/important.log":    "re-included by pkg/.gitignore",
+		"pkg/local.txt":        "ignored in pkg",
+		"pkg/sub/local.txt":    "anchored rule does not apply here",
+		"pkg/cache/data.txt":   "ignored directory",
+		"other/important.log":  "negation only applies under pkg",
+		"other/cache/keep.txt": "cache/ only ignored under pkg",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, UseGitignore: true}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	for _, path := range []string{"main.go", "pkg/secret.txt", "pkg/important.log", "pkg/sub/local.txt", "other/cache/keep.txt"} {
+		if _, ok := fileContents[filepath.FromSlash(path)]; !ok {
+			t.Errorf("Expected file not found: %s", path)
+		}
+	}
+	for _, path := range []string{"app.log", "secret.txt", "build/out.txt", "pkg/debug.log", "pkg/local.txt", "pkg/cache/data.txt", "other/important.log"} {
+		if _, ok := fileContents[filepath.FromSlash(path)]; ok {
+			t.Errorf("File should be ignored: %s", path)
+		}
+	}
+	if containsLine(tree, "build/") {
+		t.Errorf("Ignored directory should be pruned from the tree:\n%s", tree)
+	}
+
+	// Disabled, the .gitignore files have no effect.
+	opts.UseGitignore = false
+	_, fileContents, err = buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if _, ok := fileContents["app.log"]; !ok {
+		t.Error("app.log should be included with -use-gitignore=false")
+	}
+}
+
+// TestNestedGitignoreOnly tests bypassing the root .gitignore while nested ones still apply.
+func TestNestedGitignoreOnly(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		".gitignore":         "*.md\ndocs/\n",
+		"README.md":          "# readme",
+		"docs/guide.txt":     "guide",
+		"pkg/.gitignore":     "*.gen.go\n",
+		"pkg/api.go":         "package pkg",
+		"pkg/api.gen.go":     "package pkg",
+		"pkg/sub/x.gen.go":   "package sub",
+		"other/keep.gen.go":  "package other",
+		"other/CHANGELOG.md": "changes",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, UseGitignore: true, NestedGitignoreOnly: true}
+	_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	for _, path := range []string{"README.md", "docs/guide.txt", "pkg/api.go", "other/keep.gen.go", "other/CHANGELOG.md"} {
+		if _, ok := fileContents[filepath.FromSlash(path)]; !ok {
+			t.Errorf("Expected file not found: %s", path)
+		}
+	}
+	for _, path := range []string{"pkg/api.gen.go", "pkg/sub/x.gen.go"} {
+		if _, ok := fileContents[filepath.FromSlash(path)]; ok {
+			t.Errorf("File should be ignored by pkg/.gitignore: %s", path)
+		}
+	}
+}
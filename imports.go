@@ -0,0 +1,60 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// importSpan 返回 Go 源码中 import 声明所在的字节区间 [start, end)，没有 import 时 ok 为 false
+func importSpan(src string) (start, end int, ok bool) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ImportsOnly)
+	if err != nil {
+		return 0, 0, false
+	}
+	for _, decl := range f.Decls {
+		gen, isGen := decl.(*ast.GenDecl)
+		if !isGen || gen.Tok != token.IMPORT {
+			continue
+		}
+		if !ok {
+			start = fset.Position(gen.Pos()).Offset
+			ok = true
+		}
+		end = fset.Position(gen.End()).Offset
+	}
+	return start, end, ok
+}
+
+// dedupImports 将与先前文件（按路径排序）完全相同的 Go import 块替换为一行引用注释
+func dedupImports(fileContents map[string]string) {
+	paths := make([]string, 0, len(fileContents))
+	for relPath := range fileContents {
+		if filepath.Ext(relPath) == ".go" {
+			paths = append(paths, relPath)
+		}
+	}
+	sort.Strings(paths)
+
+	firstSeen := make(map[string]string) // import 块 -> 首次出现的文件
+	for _, relPath := range paths {
+		content := fileContents[relPath]
+		start, end, ok := importSpan(content)
+		if !ok {
+			continue
+		}
+		block := content[start:end]
+		if !strings.Contains(block, "\n") {
+			continue // 单行 import 替换后并不能节省多少
+		}
+		if first, seen := firstSeen[block]; seen {
+			fileContents[relPath] = content[:start] + "// imports: same as " + filepath.ToSlash(first) + content[end:]
+			continue
+		}
+		firstSeen[block] = relPath
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDedupImports tests that repeated import blocks are replaced after the first file.
+func TestDedupImports(t *testing.T) {
+	shared := "import (\n\t\"fmt\"\n\t\"os\"\n)\n"
+	fileContents := map[string]string{
+		"a.go":      "package main\n\n" + shared + "\nfunc a() { fmt.Println(os.Args) }\n",
+		"b.go":      "package main\n\n" + shared + "\nfunc b() {}\n",
+		"c.go":      "package main\n\nimport (\n\t\"fmt\"\n\t\"strings\"\n)\n\nfunc c() {}\n",
+		"d/d.go":    "package d\n\n" + shared,
+		"notes.txt": shared,
+	}
+
+	dedupImports(fileContents)
+
+	if !strings.Contains(fileContents["a.go"], shared) {
+		t.Errorf("First file should keep its import block:\n%s", fileContents["a.go"])
+	}
+	for _, path := range []string{"b.go", "d/d.go"} {
+		if strings.Contains(fileContents[path], shared) {
+			t.Errorf("%s should not repeat the import block:\n%s", path, fileContents[path])
+		}
+		if !strings.Contains(fileContents[path], "// imports: same as a.go") {
+			t.Errorf("%s should reference a.go:\n%s", path, fileContents[path])
+		}
+	}
+	if !strings.Contains(fileContents["b.go"], "func b() {}") {
+		t.Errorf("Code after the import block should be preserved:\n%s", fileContents["b.go"])
+	}
+	if !strings.Contains(fileContents["c.go"], "\"strings\"") {
+		t.Errorf("A different import block should be kept:\n%s", fileContents["c.go"])
+	}
+	if fileContents["notes.txt"] != shared {
+		t.Errorf("Non-Go files should be untouched:\n%s", fileContents["notes.txt"])
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IngestFile 是 json 输出中的一个文件
+type IngestFile struct {
+	Path         string `json:"path" yaml:"path"`
+	Size         int    `json:"size" yaml:"size"`
+	Author       string `json:"author,omitempty" yaml:"author,omitempty"`               // 最近一次提交的作者，见 -json-git-meta
+	LastModified string `json:"last_modified,omitempty" yaml:"last_modified,omitempty"` // 最近一次提交的时间（RFC 3339）
+	Content      string `json:"content" yaml:"content"`
+}
+
+// Ingest 是一次收录结果的结构化表示，对应 -format json 和 -format yaml 的输出
+type Ingest struct {
+	Tree  string       `json:"tree" yaml:"tree"`
+	Files []IngestFile `json:"files" yaml:"files"`
+}
+
+// newIngest 由渲染好的目录树和文件内容构建 Ingest，文件按路径（以 / 分隔）排序
+func newIngest(dirStructure string, fileContents map[string]string) Ingest {
+	ing := Ingest{Tree: dirStructure, Files: make([]IngestFile, 0, len(fileContents))}
+	for relPath, content := range fileContents {
+		ing.Files = append(ing.Files, IngestFile{Path: filepath.ToSlash(relPath), Size: len(content), Content: content})
+	}
+	sort.Slice(ing.Files, func(i, j int) bool { return ing.Files[i].Path < ing.Files[j].Path })
+	return ing
+}
+
+// addGitMeta 为有提交记录的文件填写作者和最后修改时间，metas 的键为 fileContents 中的相对路径
+func (ing *Ingest) addGitMeta(metas gitHistory) {
+	for relPath, meta := range metas {
+		path := filepath.ToSlash(relPath)
+		i := sort.Search(len(ing.Files), func(i int) bool { return ing.Files[i].Path >= path })
+		if i < len(ing.Files) && ing.Files[i].Path == path {
+			ing.Files[i].Author = meta.Author
+			ing.Files[i].LastModified = meta.LastModified
+		}
+	}
+}
+
+// writeJSON 以 JSON 输出 Ingest。非法的 UTF-8 字节会被替换为 U+FFFD，保证输出是合法的 JSON。
+func writeJSON(out io.Writer, ing Ingest) error {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ing)
+}
+
+// writeYAML 以 YAML 输出 Ingest。多行内容使用 | 块标量，无法用块标量表示的内容（如含有
+// 行尾空白或控制字符）由编码器自动改用带转义的双引号形式。
+func writeYAML(out io.Writer, ing Ingest) error {
+	enc := yaml.NewEncoder(out)
+	enc.SetIndent(2)
+	if err := enc.Encode(ing); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// isIngestJSON 判断内容是否正好是 -format json 输出的 Ingest：顶层只有 tree 和 files 两个键，
+// 文件条目中也没有 IngestFile 之外的字段
+func isIngestJSON(content []byte) bool {
+	var top map[string]json.RawMessage
+	if json.Unmarshal(content, &top) != nil || len(top) != 2 || top["tree"] == nil || top["files"] == nil {
+		return false
+	}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.DisallowUnknownFields()
+	var ing Ingest
+	return dec.Decode(&ing) == nil
+}
+
+// isIngestYAML 与 isIngestJSON 相同，判断内容是否正好是 -format yaml 输出的 Ingest
+func isIngestYAML(content []byte) bool {
+	var top map[string]any
+	if yaml.Unmarshal(content, &top) != nil || len(top) != 2 || top["tree"] == nil || top["files"] == nil {
+		return false
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	var ing Ingest
+	return dec.Decode(&ing) == nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestJSONFormat tests the json output structure, ordering and escaping.
+func TestJSONFormat(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":     "package main\n\nfunc main() { println(\"<hi>\\t\") }\n",
+		"pkg/util.go": "package pkg\n",
+		"bad.txt":     "invalid \xff utf-8",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, Format: "json", ShowTree: true}
+	var buf bytes.Buffer
+	if err := writeDirectoryStructure(tempDir, opts, &buf); err != nil {
+		t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+	}
+
+	var ing Ingest
+	if err := json.Unmarshal(buf.Bytes(), &ing); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, buf.String())
+	}
+	if !containsLine(ing.Tree, "util.go") {
+		t.Errorf("Unexpected tree:\n%s", ing.Tree)
+	}
+	var paths []string
+	for _, f := range ing.Files {
+		paths = append(paths, f.Path)
+	}
+	if len(paths) != 3 || paths[0] != "bad.txt" || paths[1] != "main.go" || paths[2] != "pkg/util.go" {
+		t.Fatalf("Files not sorted by path: %v", paths)
+	}
+	if f := ing.Files[1]; f.Content != "package main\n\nfunc main() { println(\"<hi>\\t\") }\n" || f.Size != len(f.Content) {
+		t.Errorf("Unexpected main.go entry: %+v", f)
+	}
+	if f := ing.Files[0]; f.Content != "invalid � utf-8" {
+		t.Errorf("Invalid UTF-8 should be replaced, got %q", f.Content)
+	}
+	if !isOwnOutput(buf.Bytes()) {
+		t.Error("JSON output should be recognized as own output")
+	}
+}
+
+// TestYAMLFormat tests that the yaml output round-trips, including content with special characters.
+func TestYAMLFormat(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"main.go":     "package main\n\nfunc main() {\n\tprintln(\"a: b # c\")\n}\n",
+		"config.yaml": "key: value\n- item\n---\n",
+		"odd.txt":     "trailing spaces   \n  leading indent\nno final newline",
+		"one.txt":     "@special: *value",
+	}
+	writeTestFiles(t, tempDir, files)
+
+	opts := Options{ExcludeList: map[string]bool{}, Format: "yaml", ShowTree: true}
+	var buf bytes.Buffer
+	if err := writeDirectoryStructure(tempDir, opts, &buf); err != nil {
+		t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "content: |") {
+		t.Errorf("Multi-line content should use a | block scalar:\n%s", buf.String())
+	}
+
+	var ing Ingest
+	if err := yaml.Unmarshal(buf.Bytes(), &ing); err != nil {
+		t.Fatalf("Invalid YAML: %v\n%s", err, buf.String())
+	}
+	if len(ing.Files) != len(files) || !containsLine(ing.Tree, "main.go") {
+		t.Fatalf("Unexpected document: %+v", ing)
+	}
+	for _, f := range ing.Files {
+		if f.Content != files[f.Path] {
+			t.Errorf("Content of %s = %q, want %q", f.Path, f.Content, files[f.Path])
+		}
+	}
+	if !isOwnOutput(buf.Bytes()) {
+		t.Error("YAML output should be recognized as own output")
+	}
+}
+
+// TestJSONGitMeta tests that -json-git-meta adds the last commit's author and time to committed files only.
+func TestJSONGitMeta(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{"main.go": "package main"})
+	for _, args := range [][]string{
+		{"init"},
+		{"add", "main.go"},
+		{"-c", "user.name=Jane Doe", "-c", "user.email=jane@example.com", "commit", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tempDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	writeTestFiles(t, tempDir, map[string]string{"untracked.go": "package main"})
+
+	opts := Options{ExcludeList: map[string]bool{}, Format: "json", JSONGitMeta: true}
+	var out bytes.Buffer
+	if err := writeDirectoryStructure(tempDir, opts, &out); err != nil {
+		t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+	}
+	var ing Ingest
+	if err := json.Unmarshal(out.Bytes(), &ing); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if len(ing.Files) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(ing.Files))
+	}
+	committed, untracked := ing.Files[0], ing.Files[1]
+	if committed.Author != "Jane Doe" {
+		t.Errorf("author = %q, want %q", committed.Author, "Jane Doe")
+	}
+	if _, err := time.Parse(time.RFC3339, committed.LastModified); err != nil {
+		t.Errorf("last_modified = %q is not an RFC 3339 time: %v", committed.LastModified, err)
+	}
+	if untracked.Author != "" || untracked.LastModified != "" {
+		t.Errorf("Untracked files should have no git metadata, got %+v", untracked)
+	}
+	if !strings.Contains(out.String(), `"author": "Jane Doe"`) || strings.Count(out.String(), `"author"`) != 1 {
+		t.Errorf("Only the committed file should carry an author:\n%s", out.String())
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// languages 将文件扩展名映射到语言名称
+var languages = map[string]string{
+	".go":    "Go",
+	".py":    "Python",
+	".js":    "JavaScript",
+	".mjs":   "JavaScript",
+	".jsx":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".scala": "Scala",
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".hpp":   "C++",
+	".cs":    "C#",
+	".rs":    "Rust",
+	".rb":    "Ruby",
+	".php":   "PHP",
+	".swift": "Swift",
+	".sh":    "Shell",
+	".bash":  "Shell",
+	".lua":   "Lua",
+	".sql":   "SQL",
+	".html":  "HTML",
+	".css":   "CSS",
+	".scss":  "SCSS",
+	".md":    "Markdown",
+	".json":  "JSON",
+	".yaml":  "YAML",
+	".yml":   "YAML",
+	".toml":  "TOML",
+	".xml":   "XML",
+	".proto": "Protocol Buffers",
+}
+
+// interpreters 将 shebang 中的解释器名称（去掉版本号）映射到语言名称
+var interpreters = map[string]string{
+	"sh":      "Shell",
+	"bash":    "Shell",
+	"zsh":     "Shell",
+	"python":  "Python",
+	"node":    "JavaScript",
+	"ruby":    "Ruby",
+	"php":     "PHP",
+	"lua":     "Lua",
+	"ts-node": "TypeScript",
+}
+
+// commentStyles 给出各语言注释的前缀与后缀，没有注释语法的语言（如 JSON）不在表中
+var commentStyles = map[string][2]string{
+	"Go":               {"// ", ""},
+	"JavaScript":       {"// ", ""},
+	"TypeScript":       {"// ", ""},
+	"Java":             {"// ", ""},
+	"Kotlin":           {"// ", ""},
+	"Scala":            {"// ", ""},
+	"C":                {"// ", ""},
+	"C++":              {"// ", ""},
+	"C#":               {"// ", ""},
+	"Rust":             {"// ", ""},
+	"PHP":              {"// ", ""},
+	"Swift":            {"// ", ""},
+	"Protocol Buffers": {"// ", ""},
+	"SCSS":             {"// ", ""},
+	"Python":           {"# ", ""},
+	"Ruby":             {"# ", ""},
+	"Shell":            {"# ", ""},
+	"YAML":             {"# ", ""},
+	"TOML":             {"# ", ""},
+	"Lua":              {"-- ", ""},
+	"SQL":              {"-- ", ""},
+	"CSS":              {"/* ", " */"},
+	"HTML":             {"<!-- ", " -->"},
+	"XML":              {"<!-- ", " -->"},
+	"Markdown":         {"<!-- ", " -->"},
+}
+
+// detectLanguage 根据扩展名返回文件的语言名称，无法识别时返回空字符串
+func detectLanguage(relPath string) string {
+	return languages[strings.ToLower(filepath.Ext(relPath))]
+}
+
+// detectContentLanguage 先按扩展名识别语言，识别不了时再看 shebang 行
+func detectContentLanguage(relPath, content string) string {
+	if lang := detectLanguage(relPath); lang != "" {
+		return lang
+	}
+	if !strings.HasPrefix(content, "#!") {
+		return ""
+	}
+	line, _, _ := strings.Cut(content[2:], "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	// #!/usr/bin/env python3 的解释器在第二个字段
+	interp := filepath.Base(fields[0])
+	if interp == "env" {
+		if len(fields) < 2 {
+			return ""
+		}
+		interp = fields[1]
+	}
+	return interpreters[strings.TrimRight(interp, "0123456789.")]
+}
+
+// parseLanguages 将逗号分隔的语言名称解析为小写集合
+func parseLanguages(s string) map[string]bool {
+	langs := make(map[string]bool)
+	for _, lang := range strings.Split(s, ",") {
+		if lang = strings.ToLower(strings.TrimSpace(lang)); lang != "" {
+			langs[lang] = true
+		}
+	}
+	return langs
+}
+
+// parseLangPreamble 解析 "lang:note" 形式的 -lang-preamble 规则，以第一个 : 分隔，语言名转为小写
+func parseLangPreamble(s string) (lang, note string, err error) {
+	lang, note, ok := strings.Cut(s, ":")
+	lang, note = strings.ToLower(strings.TrimSpace(lang)), strings.TrimSpace(note)
+	if !ok || lang == "" || note == "" {
+		return "", "", fmt.Errorf("invalid rule %q, want lang:note", s)
+	}
+	return lang, note, nil
+}
+
+// preambleFor 在某种语言的第一个文件之前返回该语言的前言，其余情况返回空串。seen 记录已输出前言的语言。
+func preambleFor(relPath, content string, preambles map[string]string, seen map[string]bool) string {
+	lang := strings.ToLower(detectContentLanguage(relPath, content))
+	note, ok := preambles[lang]
+	if !ok || seen[lang] {
+		return ""
+	}
+	seen[lang] = true
+	return note
+}
+
+// addPathComment 在文件内容开头（shebang 之后）插入一行注明路径的注释，
+// 语言未知或没有注释语法时原样返回
+func addPathComment(relPath, content string) string {
+	style, ok := commentStyles[detectLanguage(relPath)]
+	if !ok {
+		return content
+	}
+	comment := style[0] + "path: " + filepath.ToSlash(relPath) + style[1] + "\n"
+	if strings.HasPrefix(content, "#!") {
+		if end := strings.Index(content, "\n"); end >= 0 {
+			return content[:end+1] + comment + content[end+1:]
+		}
+	}
+	return comment + content
+}
+
+// langStat 是某种语言在输出中所占的字节数与百分比
+type langStat struct {
+	Language string
+	Bytes    int
+	Percent  float64
+}
+
+// languageBreakdown 按语言统计内容字节数，按字节数降序排列（相同时按名称），无法识别的归入 Other
+func languageBreakdown(fileContents map[string]string) []langStat {
+	bytesByLang := make(map[string]int)
+	total := 0
+	for relPath, content := range fileContents {
+		lang := detectLanguage(relPath)
+		if lang == "" {
+			lang = "Other"
+		}
+		bytesByLang[lang] += len(content)
+		total += len(content)
+	}
+
+	stats := make([]langStat, 0, len(bytesByLang))
+	for lang, n := range bytesByLang {
+		stat := langStat{Language: lang, Bytes: n}
+		if total > 0 {
+			stat.Percent = float64(n) * 100 / float64(total)
+		}
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Bytes != stats[j].Bytes {
+			return stats[i].Bytes > stats[j].Bytes
+		}
+		return stats[i].Language < stats[j].Language
+	})
+	return stats
+}
+
+// printLanguageStats 输出类似 GitHub 语言条的占比列表
+func printLanguageStats(w io.Writer, fileContents map[string]string) {
+	fmt.Fprintln(w, "Language breakdown:")
+	for _, stat := range languageBreakdown(fileContents) {
+		fmt.Fprintf(w, "  %-16s %5.1f%%  (%d bytes)\n", stat.Language, stat.Percent, stat.Bytes)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestDetectLanguage tests the extension to language mapping.
+func TestDetectLanguage(t *testing.T) {
+	tests := map[string]string{
+		"main.go":          "Go",
+		"scripts/build.PY": "Python",
+		"web/app.tsx":      "TypeScript",
+		"Makefile":         "",
+		"data.unknown":     "",
+	}
+	for path, want := range tests {
+		if got := detectLanguage(path); got != want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+// TestContentLangs tests that only the selected languages keep their contents.
+func TestContentLangs(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":   "package main",
+		"tool.py":   "print('hi')",
+		"README.md": "# readme",
+		"app.js":    "console.log(1)",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, ContentLangs: parseLanguages("go, Python")}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	expected := map[string]string{
+		"main.go":   "package main",
+		"tool.py":   "print('hi')",
+		"README.md": contentOmitted,
+		"app.js":    contentOmitted,
+	}
+	for path, want := range expected {
+		if got := fileContents[path]; got != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+	}
+	for path := range expected {
+		if !containsLine(tree, path) {
+			t.Errorf("Tree is missing %s:\n%s", path, tree)
+		}
+	}
+}
+
+// TestAddPathComment tests the comment syntax used for each language.
+func TestAddPathComment(t *testing.T) {
+	tests := []struct {
+		path, content, want string
+	}{
+		{"cmd/main.go", "package main\n", "// path: cmd/main.go\npackage main\n"},
+		{"tool.py", "print(1)\n", "# path: tool.py\nprint(1)\n"},
+		{"run.sh", "#!/bin/sh\necho hi\n", "#!/bin/sh\n# path: run.sh\necho hi\n"},
+		{"q.sql", "SELECT 1;\n", "-- path: q.sql\nSELECT 1;\n"},
+		{"style.css", "a {}\n", "/* path: style.css */\na {}\n"},
+		{"docs/index.html", "<p></p>\n", "<!-- path: docs/index.html -->\n<p></p>\n"},
+		{"data.json", "{}\n", "{}\n"},
+		{"LICENSE", "MIT\n", "MIT\n"},
+	}
+	for _, tt := range tests {
+		if got := addPathComment(tt.path, tt.content); got != tt.want {
+			t.Errorf("addPathComment(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestLanguageBreakdown tests the per-language byte percentages.
+func TestLanguageBreakdown(t *testing.T) {
+	fileContents := map[string]string{
+		"main.go":   "123456", // 6 bytes
+		"util.go":   "12",     // 2 bytes
+		"tool.py":   "1234",   // 4 bytes
+		"README.md": "12345",  // 5 bytes
+		"LICENSE":   "123",    // 3 bytes
+	}
+
+	stats := languageBreakdown(fileContents)
+	want := []langStat{
+		{"Go", 8, 40},
+		{"Markdown", 5, 25},
+		{"Python", 4, 20},
+		{"Other", 3, 15},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("Got %d languages, want %d: %+v", len(stats), len(want), stats)
+	}
+	sum := 0.0
+	for i, stat := range stats {
+		if stat.Language != want[i].Language || stat.Bytes != want[i].Bytes || math.Abs(stat.Percent-want[i].Percent) > 0.01 {
+			t.Errorf("stats[%d] = %+v, want %+v", i, stat, want[i])
+		}
+		sum += stat.Percent
+	}
+	if math.Abs(sum-100) > 0.01 {
+		t.Errorf("Percentages sum to %.2f, want 100", sum)
+	}
+}
+
+// TestAnnotateLang tests that file headers carry the language detected from the extension or shebang.
+func TestAnnotateLang(t *testing.T) {
+	fileContents := map[string]string{
+		"main.go":      "package main\n",
+		"web/app.ts":   "export {}\n",
+		"scripts/tool": "#!/usr/bin/env python3\nprint('hi')\n",
+		"run":          "#!/bin/bash\necho hi\n",
+		"LICENSE":      "MIT\n",
+	}
+	var out strings.Builder
+	opts := Options{ShowBanners: true, AnnotateLang: true}
+	if err := writeOutput(&out, "", fileContents, opts); err != nil {
+		t.Fatalf("writeOutput() returned error: %v", err)
+	}
+
+	for _, header := range []string{
+		"File: main.go (Go)",
+		"File: web/app.ts (TypeScript)",
+		"File: scripts/tool (Python)",
+		"File: run (Shell)",
+		"File: LICENSE",
+	} {
+		if !containsLine(out.String(), header) {
+			t.Errorf("Expected header %q in output:\n%s", header, out.String())
+		}
+	}
+}
+
+// TestLangPreamble tests that a language's preamble is written once, before its first file.
+func TestLangPreamble(t *testing.T) {
+	fileContents := map[string]string{
+		"a.md":       "# docs\n",
+		"cmd/app.go": "package main\n",
+		"main.go":    "package main\n",
+		"z.py":       "print('hi')\n",
+	}
+	lang, note, err := parseLangPreamble("Go: This is idiomatic Go.")
+	if err != nil {
+		t.Fatalf("parseLangPreamble() returned error: %v", err)
+	}
+	opts := Options{LangPreambles: map[string]string{lang: note}}
+	var out strings.Builder
+	if err := writeOutput(&out, "", fileContents, opts); err != nil {
+		t.Fatalf("writeOutput() returned error: %v", err)
+	}
+	s := out.String()
+
+	if strings.Count(s, "This is idiomatic Go.") != 1 {
+		t.Errorf("Preamble should appear exactly once:\n%s", s)
+	}
+	if !strings.Contains(s, "This is idiomatic Go.\n\nFile: cmd/app.go\n") {
+		t.Errorf("Preamble should precede the first Go file:\n%s", s)
+	}
+	if strings.Index(s, "File: a.md") > strings.Index(s, "This is idiomatic Go.") {
+		t.Errorf("Preamble should not move ahead of files of other languages:\n%s", s)
+	}
+
+	for _, bad := range []string{"go", "go:", ":note"} {
+		if _, _, err := parseLangPreamble(bad); err == nil {
+			t.Errorf("parseLangPreamble(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+)
+
+// licenseStrippedNote 是被移除的许可证头的占位说明
+const licenseStrippedNote = "[license header stripped]"
+
+// leadingCommentBlock 返回文件开头的注释块（支持 //、# 和 /* */），以及注释块之后的内容。
+// 首行的 shebang 会被保留在 prefix 中。
+func leadingCommentBlock(content string) (prefix, block, rest string, ok bool) {
+	if strings.HasPrefix(content, "#!") {
+		end := strings.Index(content, "\n")
+		if end < 0 {
+			return "", "", content, false
+		}
+		prefix, content = content[:end+1], content[end+1:]
+	}
+
+	if strings.HasPrefix(strings.TrimLeft(content, " \t"), "/*") {
+		end := strings.Index(content, "*/")
+		if end < 0 {
+			return "", "", content, false
+		}
+		end += len("*/")
+		if nl := strings.Index(content[end:], "\n"); nl >= 0 {
+			end += nl + 1
+		} else {
+			end = len(content)
+		}
+		return prefix, content[:end], content[end:], true
+	}
+
+	var marker string
+	for _, m := range []string{"//", "#"} {
+		if strings.HasPrefix(strings.TrimLeft(content, " \t"), m) {
+			marker = m
+			break
+		}
+	}
+	if marker == "" {
+		return "", "", content, false
+	}
+	end := 0
+	for end < len(content) {
+		lineEnd := strings.Index(content[end:], "\n")
+		line := content[end:]
+		if lineEnd >= 0 {
+			line = content[end : end+lineEnd+1]
+		}
+		if !strings.HasPrefix(strings.TrimLeft(line, " \t"), marker) {
+			break
+		}
+		end += len(line)
+	}
+	return prefix, content[:end], content[end:], true
+}
+
+// normalizeComment 去掉注释符号和多余空白并转为小写，用于比较注释文本
+func normalizeComment(block string) string {
+	var words []string
+	for _, line := range strings.Split(block, "\n") {
+		line = strings.TrimSpace(line)
+		for _, m := range []string{"/*", "*/", "//", "#", "*"} {
+			line = strings.TrimPrefix(line, m)
+			line = strings.TrimSuffix(line, m)
+		}
+		words = append(words, strings.Fields(line)...)
+	}
+	return strings.ToLower(strings.Join(words, " "))
+}
+
+// isLicenseHeader 判断注释块是否为许可证头。提供样例时要求与样例文本一致，
+// 否则只要包含 copyright 或 license 字样即视为许可证头。
+func isLicenseHeader(block, sample string) bool {
+	text := normalizeComment(block)
+	if sample != "" {
+		return text == sample
+	}
+	return strings.Contains(text, "copyright") || strings.Contains(text, "license")
+}
+
+// stripLicenseHeader 移除文件开头的许可证头，并留下一行说明
+func stripLicenseHeader(content, sample string) string {
+	prefix, block, rest, ok := leadingCommentBlock(content)
+	if !ok || !isLicenseHeader(block, sample) {
+		return content
+	}
+	trimmed := strings.TrimLeft(block, " \t")
+	var note string
+	switch {
+	case strings.HasPrefix(trimmed, "/*"):
+		note = "/* " + licenseStrippedNote + " */\n"
+	case strings.HasPrefix(trimmed, "//"):
+		note = "// " + licenseStrippedNote + "\n"
+	default:
+		note = "# " + licenseStrippedNote + "\n"
+	}
+	return prefix + note + rest
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestStripLicenseHeaders tests that shared license headers are removed and noted.
+func TestStripLicenseHeaders(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	goHeader := "// Copyright 2024 The Authors.\n// Licensed under the Apache License, Version 2.0.\n"
+	writeTestFiles(t, tempDir, map[string]string{
+		"a.go":      goHeader + "\npackage a\n",
+		"b.go":      goHeader + "\npackage b\n",
+		"c.py":      "#!/usr/bin/env python3\n# Copyright 2024 The Authors.\n# Licensed under the Apache License, Version 2.0.\nprint('c')\n",
+		"d.c":       "/*\n * Copyright 2024 The Authors.\n * Licensed under the Apache License, Version 2.0.\n */\nint d;\n",
+		"doc.go":    "// Package doc does things.\npackage doc\n",
+		"plain.txt": "no header here\n",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, StripLicenseHeaders: true}
+	_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	expected := map[string]string{
+		"a.go":      "// [license header stripped]\n\npackage a\n",
+		"b.go":      "// [license header stripped]\n\npackage b\n",
+		"c.py":      "#!/usr/bin/env python3\n# [license header stripped]\nprint('c')\n",
+		"d.c":       "/* [license header stripped] */\nint d;\n",
+		"doc.go":    "// Package doc does things.\npackage doc\n",
+		"plain.txt": "no header here\n",
+	}
+	for path, want := range expected {
+		if got := fileContents[path]; got != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+	}
+}
+
+// TestStripLicenseHeadersWithSample tests matching against a sample header.
+func TestStripLicenseHeadersWithSample(t *testing.T) {
+	sample := normalizeComment("Copyright 2024 The Authors.\nLicensed under the Apache License, Version 2.0.")
+	header := "// Copyright 2024 The Authors.\n// Licensed under the Apache License, Version 2.0.\n"
+	other := "// Copyright 1999 Someone Else. MIT License.\n"
+
+	if got := stripLicenseHeader(header+"package a\n", sample); !strings.HasPrefix(got, "// [license header stripped]") {
+		t.Errorf("Matching header was not stripped: %q", got)
+	}
+	if got := stripLicenseHeader(other+"package b\n", sample); got != other+"package b\n" {
+		t.Errorf("Non-matching header should be kept: %q", got)
+	}
+}
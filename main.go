@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"io"
@@ -8,29 +9,340 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 var (
 	excludeExtensions string
+	includeExtensions string
 	outputFilename    string
 	includeSizeLimit  bool
-	sizeLimit         int64
+	sizeLimit         = byteSize(50 * 1024)
+	maxTokensPerFile  int
+	countTokens       bool
+	warnFileCount     int
+	maxTokens         int
+	excludeOwner      string
+	compactTree       bool
+	symlinkTargets    bool
+	followSymlinks    bool
+	allowOutside      bool
+	strict            bool
+	skipDirs          string
+	noDefaultSkips    bool
+	entrypoint        string
+	dedupImportBlocks bool
+	olderThan         time.Duration
+	renderMode        string
+	showTree          bool
+	showBanners       bool
+	showTOC           bool
+	showMeta          bool
+	showSizes         bool
+	excludeRegex      string
+	treeCounts        bool
+	manifestPath      string
+	resumeManifest    string
+	stripLicenses     bool
+	licenseSample     string
+	outputFormat      string
+	fenceSize         int
+	lineNumbers       bool
+	treeMaxEntries    int
+	contentLangs      string
+	pathComments      bool
+	ignorePatterns    stringList
+	excludeExec       bool
+	maxPathLength     int
+	readJobs          int
+	parallelHash      bool
+	mergeSmallFiles   int
+	binaryAsHex       bool
+	includeBinary     bool
+	binaryHexMaxSize  int
+	languageStats     bool
+	importsDepth      int
+	skipIfUnchanged   bool
+	useDockerignore   bool
+	annotateLang      bool
+	treeOnlyGlob      string
+	printExcluded     bool
+	quiet             bool
+	statsHeader       bool
+	excludedOut       string
+	expandTabWidth    int
+	printDigest       bool
+	autoSkipRules     stringList
+	headGlobRules     stringList
+	verifyManifest    string
+	reindentWidth     int
+	listRelatedTests  bool
+	groupTests        bool
+	sortOrder         string
+	pathCase          string
+	runMode           string
+	replaceRules      stringList
+	langPreambles     stringList
+	replaceRegexRules stringList
+	docAnchors        bool
+	docFiles          string
+	includeDirs       string
+	optionsStdin      bool
+	configPath        string
+	depthGlobRules    stringList
+	excludeGlobs      string
+	useGitignore      bool
+	nestedIgnoreOnly  bool
+	stripBlankCode    bool
+	codeExtensions    string
+	reportDuplicates  bool
+	transformGlobs    stringList
+	transformOrder    string
+	dryRun            bool
+	compressOutput    bool
+	jsonGitMeta       bool
+	skipDataFiles     bool
+	truncMarker       string
+	gitLogSummary     int
+	maxOutput         byteSize
+	contentMaxBytes   byteSize
+	denyContent       string
+	treeRollup        bool
+	showVersion       bool
+	initConfig        bool
+	forceInit         bool
+	dataFileRatio     float64
 )
 
+// Options 汇总一次运行所需的全部配置
+type Options struct {
+	ExcludeList         map[string]bool
+	SkipDirs            map[string]bool // 遍历时跳过的目录名（如 node_modules），不出现在目录树中
+	IncludeList         map[string]bool // 只收录这些扩展名的文件，nil 表示不限制；先于 ExcludeList 生效
+	IncludeSizeLimit    bool
+	SizeLimit           int64
+	MaxTokensPerFile    int               // 单个文件的 token 上限，0 表示不限制
+	ContentMaxBytes     int64             // 单个文件内容的字节上限，按整行截断，0 表示不限制
+	MaxTokens           int               // 按输出顺序累计的 token 预算，超出后的文件不写入输出，0 表示不限制
+	MaxOutput           int64             // 按输出顺序累计的内容字节预算，超出后的文件不写入输出，0 表示不限制
+	ExcludeOwners       map[uint32]bool   // 需要排除的文件属主 uid
+	CompactTree         bool              // 折叠只有单个子目录的目录链
+	SymlinkTargets      bool              // 在目录树中标注符号链接的目标，如 name -> target
+	FollowSymlinks      bool              // 进入指向目录的符号链接
+	AllowOutside        bool              // 与 FollowSymlinks 一起使用，也进入指向仓库之外的目录链接
+	Strict              bool              // 遇到无法读取的文件或目录时中止，默认警告并跳过
+	Reachable           map[string]bool   // 非 nil 时只收录其中的文件（相对路径），见 -entrypoint
+	DedupImports        bool              // 将重复的 Go import 块替换为引用
+	OlderThan           time.Duration     // 排除超过该时长未修改的文件，0 表示不限制
+	ShowTree            bool              // 输出目录树
+	ShowBanners         bool              // 文件头使用 ==== 横幅
+	ShowTOC             bool              // 输出文件列表
+	ShowMeta            bool              // 输出文件数、总大小等元信息
+	StatsHeader         bool              // 在输出开头以注释块写出文件数、大小、token 估算和语言分布
+	GitLogSummary       int               // 在 txt 和 md 输出开头列出的最近提交数，0 表示不列出
+	RecentCommits       []string          // 最近的提交（"短哈希 标题"），开启 GitLogSummary 时在收录后查询
+	ShowSizes           bool              // 文件头中标注文件大小
+	ExcludeRegex        *regexp.Regexp    // 匹配仓库相对路径（以 / 分隔）的文件将被排除
+	ExcludeGlobs        []*regexp.Regexp  // 由 -exclude-glob 编译而来，匹配仓库相对路径的文件将被排除
+	TreeCounts          bool              // 在目录节点后标注收录的文件数
+	ManifestPath        string            // 非空时将本次收录文件的清单写入该路径
+	Verify              bool              // 开启 -verify，ingest 在 Snapshot 中记录与清单相同的快照
+	Snapshot            manifest          // ingest 记录的清单快照，只在写清单或 -verify 时计算
+	Resume              map[string]string // 先前清单中的 路径 -> 哈希，未变化的文件不再输出内容
+	StripLicenseHeaders bool              // 移除文件开头的许可证注释
+	LicenseSample       string            // 规范化后的许可证样例文本，为空时按 copyright/license 字样识别
+	Format              string            // 输出格式：txt 或 fileFormats 中注册的格式
+	FenceSize           int               // md 格式代码块围栏的最小反引号数
+	LineNumbers         bool              // txt 和 md 格式中在文件内容每行前加行号
+	TreeMaxEntries      int               // 目录树中每个目录最多显示的条目数，0 表示不限制
+	TruncationMarker    string            // 目录树中被截断的分支的标记，为空时使用 defaultTruncationMarker
+	TreeRollup          bool              // tree-json 格式中为每个目录加上其下所有文件的总大小
+	ContentLangs        map[string]bool   // 非 nil 时只输出这些语言（小写）文件的内容，其余文件仅列出
+	LangPreambles       map[string]string // 语言（小写）-> 写在该语言第一个文件之前的前言
+	PathComments        bool              // 在文件内容开头插入注明路径的注释
+	Ignore              *ignoreMatcher    // gitignore 风格的忽略规则（来自命令行和 .dockerignore）
+	UseGitignore        bool              // 遍历时读取仓库根目录及各子目录中的 .gitignore
+	NestedGitignoreOnly bool              // 与 UseGitignore 一起使用，跳过根目录的 .gitignore，只读取子目录中的
+	ExcludeExecutable   bool              // 排除设置了可执行权限位的文件
+	MaxPathLength       int               // 相对路径超过该字符数的文件被排除，0 表示不限制
+	Jobs                int               // 并发读取文件的 goroutine 数，0 表示 runtime.NumCPU()
+	ParallelHash        bool              // 用与读取文件相同数量的 goroutine 并发计算文件哈希
+	MergeSmallFiles     int               // 小于该字节数的文件合并到一个块中输出，0 表示不合并
+	BinaryAsHex         bool              // 以 hexdump -C 格式输出二进制文件
+	IncludeBinary       bool              // 收录二进制文件的原始内容，默认跳过
+	BinaryHexMaxSize    int               // 超过该字节数的二进制文件不做十六进制转储，0 表示不限制
+	LanguageStats       bool              // 结束时向 stderr 输出按语言统计的字节占比
+	AnnotateLang        bool              // 在文件标题中注明识别出的语言
+	TreeOnlyGlob        *regexp.Regexp    // 只影响目录树：仅显示匹配该 glob 的条目
+	Excluded            *exclusionLog     // 非 nil 时记录被排除的文件及原因，用于 -print-excluded 和运行摘要
+	ExpandTabs          int               // 将内容中的制表符展开为该宽度的空格，0 表示保留制表符
+	AutoSkipDirs        []autoSkipRule    // 直接包含过多匹配文件的目录将被整体跳过
+	HeadRules           []headRule        // 路径匹配的文件只输出开头若干行
+	Transforms          []transformRule   // 只作用于路径匹配的文件的变换，见 -transform-glob
+	TransformOrder      []string          // 内容变换的执行顺序，nil 表示 defaultTransformOrder
+	Reindent            int               // 将缩进统一为每层该数量的空格，0 表示不处理
+	StripBlankLines     map[string]bool   // 非 nil 时删除这些扩展名（代码文件）中的所有空行
+	ListRelatedTests    bool              // 在 Go 源文件开头注明同名 _test.go 中的测试函数
+	GroupTests          bool              // 把测试文件紧跟在对应的源文件之后输出
+	SortOrder           string            // 文件内容的输出顺序：path（默认）、size-desc 或 size-asc
+	PathCase            string            // 输出路径的大小写：preserve 或 lower
+	Overview            bool              // 只输出目录树和每个文件的一行摘要，见 -mode overview
+	OutputPath          string            // 输出文件的绝对路径，遍历时跳过，避免收录自身的输出
+	Replacements        []*replaceRule    // 按顺序对文件内容执行的替换，见 -replace 和 -replace-regex
+	DocAnchors          []string          // 非 nil 时把这些目录级文档放在各目录文件之前输出
+	IncludeDirs         []string          // 非空时只收录这些子目录（相对路径）中的内容，见 -dir
+	DepthRules          []depthRule       // 在匹配的目录之下限制遍历深度
+	DryRun              bool              // ingest 在确定收录的文件后即返回，不写清单，见 -dry-run
+	JSONGitMeta         bool              // 在 json 和 yaml 输出中为每个文件加上最近一次提交的作者和时间
+	GitMeta             gitHistory        // 相对路径 -> 最近一次提交，开启 JSONGitMeta 时在收录后查询
+	SkipDataFiles       bool              // 跳过非字母字符与字母之比过高的数据文件（如 CSV 转储）
+	DataFileRatio       float64           // SkipDataFiles 的阈值：非字母字符数 / 字母数
+	DenyContent         []string          // 内容中含有其中任一字符串的文件被排除，见 -deny-content
+}
+
+// stdoutName 是表示写到标准输出的 -o 取值
+const stdoutName = "-"
+
+// contentOmitted 是未输出内容的文件的占位文本
+const contentOmitted = "[content omitted]"
+
+// stringList 是可重复指定的字符串 flag
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// defaultSkipDirs 是默认跳过的目录名，可用 -no-default-skips 关闭
+var defaultSkipDirs = []string{"node_modules", "vendor"}
+
+// streamFormats 是由 render 写入 io.Writer 的内置格式
+var streamFormats = map[string]bool{"txt": true, "md": true, "json": true, "yaml": true, "xml": true, "tree-json": true}
+
+// fileFormats 保存直接写入输出路径（而不是 io.Writer）的格式，如 sqlite。
+// 可选格式在各自的文件中通过 init 注册。
+var fileFormats = map[string]func(path string, fileContents map[string]string) error{}
+
 func init() {
+	flag.StringVar(&includeDirs, "dir", "", "Comma-separated list of subdirectories (relative to the repository root) to ingest; everything outside them is skipped")
+	flag.StringVar(&includeDirs, "d", "", "Shorthand for -dir")
+	flag.StringVar(&configPath, "config", "", "YAML file of default options keyed by flag name (default: .gitingest.yaml in the repository root, if present); command-line flags take precedence")
+	flag.BoolVar(&optionsStdin, "options-stdin", false, "Read options as a JSON object keyed by flag name (e.g. {\"max-size\": 1024}) from stdin; command-line flags take precedence")
+	flag.StringVar(&skipDirs, "skip-dirs", "", "Comma-separated directory names to skip wherever they occur, in addition to node_modules and vendor (unless -no-default-skips)")
+	flag.BoolVar(&noDefaultSkips, "no-default-skips", false, "Do not skip node_modules and vendor directories by default")
+	flag.BoolVar(&showVersion, "version", false, "Print the version, commit and build date, then exit")
+	flag.BoolVar(&initConfig, "init", false, "Write a commented "+defaultConfigName+" template with all options and their defaults to the repository root, then exit")
+	flag.BoolVar(&forceInit, "force", false, "With -init, overwrite an existing config file")
 	flag.StringVar(&excludeExtensions, "exclude", "", "Comma-separated list of file extensions to exclude (e.g., .jpg,.png,.gif)")
-	flag.StringVar(&outputFilename, "o", "output.txt", "Output file name")
+	flag.StringVar(&includeExtensions, "include", "", "Comma-separated allowlist of file extensions; when set, only these are included, then -exclude applies (e.g., .go,.md)")
+	flag.StringVar(&outputFilename, "o", "output.txt", "Output file name ('-' writes to stdout)")
+	flag.BoolVar(&compressOutput, "compress", false, "Gzip the output (done automatically when -o ends in .gz)")
+	flag.BoolVar(&skipIfUnchanged, "skip-if-unchanged", false, "Do not rewrite the output file if its content would be identical; exit with status 3 instead")
+	flag.StringVar(&outputFormat, "format", "txt", "Output format: txt, md (Markdown with fenced code blocks), json or yaml (tree and files with contents), xml (files wrapped in <documents>), tree-json (directory tree with sizes, no contents), or sqlite (requires building with -tags sqlite)")
+	flag.BoolVar(&jsonGitMeta, "json-git-meta", false, "In the json and yaml formats, add each file's last commit author and time (author, last_modified); untracked files get neither")
+	flag.BoolVar(&lineNumbers, "line-numbers", false, "Prefix each line of file contents with its right-aligned line number and ' | ' in the txt and md formats")
+	flag.IntVar(&fenceSize, "fence-size", 3, "With -format md, the minimum number of backticks in every code fence; longer fences are still used when content contains backtick runs")
 	flag.BoolVar(&includeSizeLimit, "size-limit", false, "Enable file size limit")
-	flag.Int64Var(&sizeLimit, "max-size", 50*1024, "Maximum file size in bytes (default: 50KB)") // 50KB default
+	flag.Var(&sizeLimit, "max-size", "Maximum file `size`, in bytes or with a suffix such as 500k or 1.5MB")
+	flag.Var(&headGlobRules, "head-glob", "Only include the first N lines of files matching glob, given as glob:N (e.g. '*.sql:50'; repeatable, first match wins)")
+	flag.Var(&transformGlobs, "transform-glob", "Apply a transform to files matching glob, given as glob:transform[=N] (head, tail, expand-tabs, reindent, strip-blank-lines; e.g. 'testdata/**:head=10'; repeatable)")
+	flag.StringVar(&transformOrder, "transform-order", strings.Join(defaultTransformOrder, ","), "Comma-separated order of the content transforms; transforms left out run afterwards in the default order")
+	flag.Var(&contentMaxBytes, "content-max-bytes", "Truncate each file's content to at most this many bytes, at a line boundary, with a truncation note; accepts suffixes like 4k (0 = no limit)")
+	flag.IntVar(&maxTokensPerFile, "max-tokens-per-file", 0, "Truncate files whose estimated token count exceeds N (0 = no limit)")
+	flag.IntVar(&warnFileCount, "warn-file-count", 1000, "Print a warning suggesting tighter filters when more than N files are included (0 = never)")
+	flag.BoolVar(&countTokens, "count-tokens", false, "Print the estimated token count (chars/4) of the included files, in total and per file, to stderr")
+	flag.Var(&maxOutput, "max-output", "Stop adding file contents once their total size exceeds this many bytes, in output order, and warn which were omitted; accepts suffixes like 500k or 10MB (0 = no limit)")
+	flag.IntVar(&maxTokens, "max-tokens", 0, "Stop adding files once their estimated total token count exceeds N, in output order, and warn which were skipped (0 = no limit)")
+	flag.StringVar(&excludeOwner, "exclude-owner", "", "Comma-separated list of owners (uid or user name) whose files are excluded (Unix only)")
+	flag.BoolVar(&compactTree, "compact-tree", false, "Collapse single-child directory chains into one tree line (e.g. a/b/c/)")
+	flag.BoolVar(&symlinkTargets, "symlink-targets", true, "Annotate symbolic links in the tree with their targets (name -> target)")
+	flag.BoolVar(&followSymlinks, "follow-symlinks", false, "Walk into symlinked directories that resolve outside the repository, together with -allow-outside (each target at most once; links into the repository are not followed, since the walk already covers their targets)")
+	flag.BoolVar(&strict, "strict", false, "Fail on the first unreadable file or directory instead of warning and skipping it")
+	flag.BoolVar(&allowOutside, "allow-outside", false, "With -follow-symlinks, walk into symlinked directories that resolve outside the repository")
+	flag.StringVar(&entrypoint, "entrypoint", "", "Only include Go files reachable from this package (e.g. ./cmd/foo), computed with go/packages")
+	flag.IntVar(&importsDepth, "follow-imports-depth", -1, "With -entrypoint, follow imports at most N levels deep (0 = entrypoint only, -1 = unlimited)")
+	flag.BoolVar(&docAnchors, "include-doc-anchors", false, "Include each directory's design docs (see -doc-files) and write them before that directory's files")
+	flag.StringVar(&docFiles, "doc-files", "CONTRIBUTING.md,ARCHITECTURE.md", "Comma-separated file names used by -include-doc-anchors")
+	flag.BoolVar(&listRelatedTests, "list-related-tests", false, "Note the test function names from each Go file's sibling _test.go at the top of its content")
+	flag.BoolVar(&groupTests, "group-tests", false, "Write each test file right after its source file (e.g. foo.go then foo_test.go) instead of in path order")
+	flag.BoolVar(&dedupImportBlocks, "dedup-imports", false, "Replace Go import blocks identical to an earlier file's with a reference")
+	flag.DurationVar(&olderThan, "older-than", 0, "Exclude files not modified within this duration (e.g. 720h)")
+	flag.StringVar(&sortOrder, "sort", "path", "Order of file contents and the TOC: path, size-desc (largest first) or size-asc")
+	flag.StringVar(&pathCase, "normalize-paths-case", "preserve", "Casing of paths in the output: preserve or lower (paths differing only in case are kept as is and warned about)")
+	flag.StringVar(&runMode, "mode", "full", "Output mode: full (default) or overview (prompt header, tree and one-line summaries per file, no contents; txt format only)")
+	flag.StringVar(&renderMode, "render", "standard", "Output preset: minimal (contents only), standard, or rich (adds meta, TOC and sizes)")
+	flag.BoolVar(&showTree, "tree", true, "Include the directory tree (overrides -render)")
+	flag.BoolVar(&showBanners, "banners", true, "Surround file headers with ==== banners (overrides -render)")
+	flag.BoolVar(&showTOC, "toc", false, "Include a table of contents listing all files (overrides -render)")
+	flag.BoolVar(&showMeta, "meta", false, "Include a header with file count and total size (overrides -render)")
+	flag.BoolVar(&showSizes, "file-sizes", false, "Show file sizes in file headers and the TOC (overrides -render)")
+	flag.StringVar(&excludeRegex, "exclude-regex", "", "Exclude files whose repo-relative path (with / separators) matches this regular expression")
+	flag.StringVar(&excludeGlobs, "exclude-glob", "", "Comma-separated path globs to exclude, matched against the repo-relative path (e.g. 'testdata/**,*.generated.go,docs/*.md')")
+	flag.BoolVar(&treeCounts, "tree-counts", false, "Annotate directories in the tree with the number of included files they contain")
+	flag.StringVar(&treeOnlyGlob, "tree-only-glob", "", "Only show tree entries matching this gitignore-style glob (e.g. 'cmd/**'); file contents are not affected")
+	flag.IntVar(&treeMaxEntries, "tree-max-entries-per-dir", 0, "Show at most N entries per directory in the tree, followed by the truncation marker and '(M more)' (0 = no limit)")
+	flag.BoolVar(&treeRollup, "json-tree-rollup", false, "With -format tree-json, give each directory a size: the total of all files below it")
+	flag.StringVar(&truncMarker, "truncation-marker", defaultTruncationMarker, "Marker for tree branches cut by -tree-max-entries-per-dir or -depth-glob")
+	flag.Var(&langPreambles, "lang-preamble", "Write a note before the first file of a language, given as lang:note (e.g. 'go:This is idiomatic Go.'; repeatable)")
+	flag.StringVar(&contentLangs, "content-langs", "", "Comma-separated languages (e.g. go,python) whose contents are included; other files are listed with [content omitted]")
+	flag.Var(&replaceRules, "replace", "Replace literal text in file contents, given as old=new (split at the first '='; repeatable)")
+	flag.Var(&replaceRegexRules, "replace-regex", "Replace regular expression matches in file contents, given as pattern=replacement ($1 expands groups; repeatable)")
+	flag.IntVar(&expandTabWidth, "expand-tabs", 0, "Expand tabs in file contents to spaces with tab stops every N columns (0 = keep tabs)")
+	flag.IntVar(&reindentWidth, "reindent", 0, "Normalize indentation in file contents to N spaces per level, detecting each file's indent unit (0 = off)")
+	flag.BoolVar(&stripBlankCode, "strip-blank-lines-in-code", false, "Remove all blank lines from code files (see -code-extensions); prose files are left untouched")
+	flag.StringVar(&codeExtensions, "code-extensions", defaultCodeExtensions, "Comma-separated extensions treated as code by -strip-blank-lines-in-code")
+	flag.BoolVar(&pathComments, "path-comments", false, "Insert a language-appropriate '// path: x' comment at the top of each file's content")
+	flag.BoolVar(&useGitignore, "use-gitignore", true, "Skip files and directories ignored by the repository's .gitignore files (including nested ones)")
+	flag.BoolVar(&nestedIgnoreOnly, "respect-nested-gitignore-only", false, "With -use-gitignore, honor only .gitignore files in subdirectories, not the root one")
+	flag.Var(&ignorePatterns, "gitignore-pattern", "Additional gitignore-style pattern to ignore, relative to the repository root (repeatable)")
+	flag.BoolVar(&useDockerignore, "dockerignore", false, "Also ignore paths matched by the .dockerignore file in the repository root")
+	flag.Var(&depthGlobRules, "depth-glob", "Limit descent below directories matching glob to N levels, given as glob:N (e.g. 'third_party/**:1'; repeatable)")
+	flag.Var(&autoSkipRules, "auto-skip-dir-if-matches", "Skip a directory that directly contains more than count files matching glob, given as glob:count (e.g. '*.json:100'; repeatable)")
+	flag.StringVar(&denyContent, "deny-content", "", "Comma-separated marker strings (e.g. 'INTERNAL ONLY,CONFIDENTIAL'); files containing any of them are skipped")
+	flag.BoolVar(&skipDataFiles, "skip-data-files", false, "Skip data dumps such as CSV or numeric JSON, detected by a high ratio of digits and punctuation to letters")
+	flag.Float64Var(&dataFileRatio, "data-file-ratio", 1.5, "With -skip-data-files, the ratio of non-letter to letter characters at which a file counts as data")
+	flag.BoolVar(&excludeExec, "exclude-executable", false, "Exclude files with any executable permission bit set")
+	flag.IntVar(&readJobs, "jobs", 0, "Number of files read and transformed concurrently (0 = number of CPUs)")
+	flag.BoolVar(&parallelHash, "parallel-hash", false, "Compute the per-file hashes for -manifest, -verify and -print-digest with -jobs workers")
+	flag.IntVar(&maxPathLength, "max-path-length", 0, "Skip files whose relative path is longer than N characters (0 = no limit)")
+	flag.IntVar(&mergeSmallFiles, "merge-small-files", 0, "Group files smaller than N bytes into one combined block with per-file delimiters (0 = off)")
+	flag.BoolVar(&binaryAsHex, "binary-as-hex", false, "Render binary files as a hexdump -C style block")
+	flag.BoolVar(&includeBinary, "include-binary", false, "Include the raw contents of binary files (detected by NUL bytes), which are skipped by default")
+	flag.IntVar(&binaryHexMaxSize, "binary-hex-max-size", 4096, "Largest binary file (bytes) rendered by -binary-as-hex; larger ones get a one-line note")
+	flag.BoolVar(&annotateLang, "annotate-lang", false, "Add the detected language (from extension or shebang) to each file header, e.g. 'File: foo (Go)'")
+	flag.BoolVar(&languageStats, "language-stats", false, "Print a percentage breakdown of the dump by language (bytes) to stderr")
+	flag.BoolVar(&statsHeader, "stats-header", false, "Start the txt or md output with a comment block of file count, total size, estimated tokens and language breakdown")
+	flag.IntVar(&gitLogSummary, "git-log-summary", 0, "Start the txt or md output with the subjects of the last N commits (0 = off)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Print the files that would be included, with their sizes, to stdout without writing the output; files are read and checked as in a real run, including -max-tokens and -max-output")
+	flag.BoolVar(&quiet, "quiet", false, "Do not print the summary and the success message after writing the output")
+	flag.BoolVar(&printExcluded, "print-excluded", false, "Print every file that was found but excluded, with the reason, to stderr")
+	flag.StringVar(&excludedOut, "excluded-out", "", "Write the excluded-files report to this file instead of stderr (implies -print-excluded)")
+	flag.BoolVar(&reportDuplicates, "report-duplicates", false, "Print groups of files with identical content to stderr (the output is not changed)")
+	flag.BoolVar(&printDigest, "digest", false, "Print a SHA-256 digest of the whole selection (sorted paths and content hashes) at the end")
+	flag.StringVar(&manifestPath, "manifest", "", "Write a JSON manifest (path, size, sha256) of the included files to this path")
+	flag.StringVar(&resumeManifest, "resume", "", "Skip files whose path and hash match this previously written manifest")
+	flag.StringVar(&verifyManifest, "verify", "", "Compare the repository against this manifest, report added/removed/changed files and exit with status 4 on any drift; no output file is written")
+	flag.BoolVar(&stripLicenses, "strip-license-headers", false, "Remove leading license comment blocks from files, leaving a short note")
+	flag.StringVar(&licenseSample, "license-header-file", "", "File containing the license header text to strip (default: any leading comment mentioning copyright or license)")
 }
 
 func usage() {
 	fmt.Println("local-gitingest: Convert a local Git repository to a single text file.")
-	fmt.Println("\nUsage: local-gitingest [options]")
+	fmt.Println("\nUsage: local-gitingest [options] [repo-path]")
 	fmt.Println("Options:")
 	flag.PrintDefaults()
-	fmt.Println("\nThis tool must be run from the root directory of a Git repository.")
+	fmt.Println("\nThe repository is the one containing repo-path, or the current directory if it is omitted;")
+	fmt.Println("the whole repository is always ingested from its root.")
 	fmt.Println("It generates a text file containing the repository's directory structure and file contents,")
 	fmt.Println("excluding specified file types and those exceeding a size limit.")
 	fmt.Println("This is useful for providing context to large language models or creating project snapshots.")
@@ -40,18 +352,212 @@ func main() {
 	flag.Usage = usage // Set custom usage function
 	flag.Parse()
 
-	// 检查是否在 Git 仓库的根目录下
-	if !isGitRoot() {
-		fmt.Fprintln(os.Stderr, "Error: This tool must be run from the root directory of a Git repository.")
+	if showVersion {
+		printVersion(os.Stdout)
+		return
+	}
+
+	// 仓库路径可以作为参数给出，默认为当前目录；从仓库内的任意目录开始，都以仓库根目录作为遍历起点
+	if flag.NArg() > 1 {
+		fmt.Fprintf(os.Stderr, "Error: expected at most one repository path, got %d arguments\n", flag.NArg())
+		os.Exit(1)
+	}
+	repoPath := "."
+	if flag.NArg() == 1 {
+		repoPath = flag.Arg(0)
+		if info, err := os.Stat(repoPath); err != nil || !info.IsDir() {
+			fmt.Fprintf(os.Stderr, "Error: %s is not a directory.\n", repoPath)
+			os.Exit(1)
+		}
+	}
+	rootDir, err := gitToplevel(repoPath)
+	if err != nil {
+		if flag.NArg() == 1 {
+			fmt.Fprintf(os.Stderr, "Error: %s is not inside a Git repository.\n", repoPath)
+		} else {
+			fmt.Fprintln(os.Stderr, "Error: This tool must be run from the root directory of a Git repository.")
+		}
+		os.Exit(1)
+	}
+
+	// -init 把模板写到仓库根目录，即 loadConfigFile 读取默认配置文件的位置
+	if initConfig {
+		path := filepath.Join(rootDir, defaultConfigName)
+		if err := initConfigFile(path, flag.CommandLine, forceInit); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote %s\n", path)
+		return
+	}
+
+	if optionsStdin {
+		if err := applyOptionsJSON(flag.CommandLine, os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading options from stdin: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	// 配置文件只提供默认值，命令行和 -options-stdin 中的选项优先
+	if err := loadConfigFile(flag.CommandLine, rootDir, configPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading config file: %v\n", err)
 		os.Exit(1)
 	}
 
-	rootDir, err := os.Getwd()
+	opts, err := newOptions(rootDir)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
+	if entrypoint != "" {
+		opts.Reachable, err = reachableFiles(rootDir, entrypoint, importsDepth)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving entrypoint: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// -dry-run 只预览选中的文件，不写输出、清单等任何文件
+	if opts.DryRun {
+		tree, fileContents, err := ingest(rootDir, &opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error walking repository: %v\n", err)
+			os.Exit(1)
+		}
+		writeDryRun(os.Stdout, dryRunFiles(tree, fileContents))
+		if printExcluded || excludedOut != "" {
+			if err := writeExcludedReport(opts.Excluded, excludedOut); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing excluded-files report: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		return
+	}
+
+	tree, fileContents, err := ingest(rootDir, &opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing directory structure: %v\n", err)
+		os.Exit(1)
+	}
+
+	printCaseCollisions(os.Stderr, caseCollisions(fileContents))
+
+	if printExcluded || excludedOut != "" {
+		if err := writeExcludedReport(opts.Excluded, excludedOut); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing excluded-files report: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if verifyManifest != "" {
+		prior, err := readManifestHashes(verifyManifest)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading manifest: %v\n", err)
+			os.Exit(1)
+		}
+		d := diffManifest(opts.Snapshot, prior)
+		writeDrift(os.Stdout, d)
+		if !d.empty() {
+			fmt.Fprintf(os.Stderr, "Repository differs from %s: %d added, %d removed, %d changed\n",
+				verifyManifest, len(d.Added), len(d.Removed), len(d.Changed))
+			os.Exit(exitDrift)
+		}
+		fmt.Printf("Repository matches %s\n", verifyManifest)
+		return
+	}
+
+	// -o - 时输出写到 stdout，状态信息改写到 stderr
+	status, target := os.Stdout, outputFilename
+	if outputFilename == stdoutName {
+		status, target = os.Stderr, "stdout"
+	}
+
+	// -o 以 .gz 结尾或指定 -compress 时以 gzip 压缩输出，sqlite 等直接写文件的格式不压缩
+	compressed := gzipOutput(outputFilename, compressOutput)
+	if writeFile, ok := fileFormats[opts.Format]; ok {
+		if err := writeFile(outputFilename, fileContents); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s output: %v\n", opts.Format, err)
+			os.Exit(1)
+		}
+	} else if skipIfUnchanged {
+		// 先在内存中生成输出，内容未变化时不重写文件，避免改变 mtime
+		var buf bytes.Buffer
+		if err := renderCompressed(&buf, tree, fileContents, opts, compressed); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing directory structure: %v\n", err)
+			os.Exit(1)
+		}
+		written, err := writeIfChanged(outputFilename, buf.Bytes())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output file: %v\n", err)
+			os.Exit(1)
+		}
+		if !written {
+			fmt.Fprintf(os.Stderr, "Output %s is unchanged, not rewriting\n", outputFilename)
+			os.Exit(exitUnchanged)
+		}
+	} else if outputFilename == stdoutName {
+		if err := renderCompressed(os.Stdout, tree, fileContents, opts, compressed); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing directory structure: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		outFile, err := os.Create(outputFilename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
+			os.Exit(1)
+		}
+		defer outFile.Close()
+
+		if err := renderCompressed(outFile, tree, fileContents, opts, compressed); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing directory structure: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if opts.LanguageStats {
+		printLanguageStats(os.Stderr, fileContents)
+	}
+	if warnFileCount > 0 {
+		printFileCountWarning(os.Stderr, len(fileContents), warnFileCount)
+	}
+	if countTokens {
+		printTokenCounts(os.Stderr, fileContents)
+	}
+	if reportDuplicates {
+		printDuplicates(os.Stderr, fileContents)
+	}
+	if len(opts.Replacements) > 0 {
+		printReplaceCounts(os.Stderr, opts.Replacements)
+	}
+
+	if !quiet {
+		newRunSummary(fileContents, opts.Excluded).write(os.Stderr)
+		fmt.Fprintf(status, "Successfully generated output to %s\n", target)
+	}
+	if printDigest {
+		fmt.Fprintf(status, "Digest: sha256:%s\n", repoDigest(fileContents, hashWorkers(opts)))
+	}
+}
+
+// writeExcludedReport 将被排除文件的报告写入 path，path 为空时写到 stderr
+func writeExcludedReport(log *exclusionLog, path string) error {
+	if path == "" {
+		fmt.Fprintln(os.Stderr, "Excluded files:")
+		return log.write(os.Stderr)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := log.write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// newOptions 根据命令行参数构建 Options，-dir 和 .dockerignore 相对于仓库根目录 rootDir
+func newOptions(rootDir string) (Options, error) {
 	// 构建排除列表，默认排除可执行文件
 	excludeList := map[string]bool{
 		"": true, // 排除没有扩展名的文件，通常是可执行文件
@@ -62,50 +568,453 @@ func main() {
 		}
 	}
 
-	outFile, err := os.Create(outputFilename)
+	// 构建允许列表，扩展名可以省略开头的 .
+	var includeList map[string]bool
+	if includeExtensions != "" {
+		includeList = map[string]bool{}
+		for _, ext := range strings.Split(includeExtensions, ",") {
+			if ext = strings.TrimSpace(ext); ext != "" && !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			includeList[ext] = true
+		}
+	}
+
+	// 构建跳过的目录名列表，默认跳过 node_modules 和 vendor
+	skipDirList := map[string]bool{}
+	if !noDefaultSkips {
+		for _, name := range defaultSkipDirs {
+			skipDirList[name] = true
+		}
+	}
+	for _, name := range strings.Split(skipDirs, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			skipDirList[name] = true
+		}
+	}
+
+	opts := Options{
+		ExcludeList:         excludeList,
+		SkipDirs:            skipDirList,
+		IncludeList:         includeList,
+		IncludeSizeLimit:    includeSizeLimit,
+		SizeLimit:           int64(sizeLimit),
+		MaxTokensPerFile:    maxTokensPerFile,
+		ContentMaxBytes:     int64(contentMaxBytes),
+		MaxTokens:           maxTokens,
+		MaxOutput:           int64(maxOutput),
+		CompactTree:         compactTree,
+		SymlinkTargets:      symlinkTargets,
+		FollowSymlinks:      followSymlinks,
+		AllowOutside:        allowOutside,
+		Strict:              strict,
+		DedupImports:        dedupImportBlocks,
+		OlderThan:           olderThan,
+		ShowTree:            showTree,
+		ShowBanners:         showBanners,
+		ShowTOC:             showTOC,
+		ShowMeta:            showMeta,
+		StatsHeader:         statsHeader,
+		GitLogSummary:       gitLogSummary,
+		ShowSizes:           showSizes,
+		TreeCounts:          treeCounts,
+		ManifestPath:        manifestPath,
+		Verify:              verifyManifest != "",
+		StripLicenseHeaders: stripLicenses,
+		Format:              outputFormat,
+		FenceSize:           fenceSize,
+		LineNumbers:         lineNumbers,
+		TreeMaxEntries:      treeMaxEntries,
+		TruncationMarker:    truncMarker,
+		TreeRollup:          treeRollup,
+		PathComments:        pathComments,
+		UseGitignore:        useGitignore,
+		NestedGitignoreOnly: nestedIgnoreOnly,
+		ExcludeExecutable:   excludeExec,
+		MaxPathLength:       maxPathLength,
+		Jobs:                readJobs,
+		ParallelHash:        parallelHash,
+		MergeSmallFiles:     mergeSmallFiles,
+		BinaryAsHex:         binaryAsHex,
+		IncludeBinary:       includeBinary,
+		BinaryHexMaxSize:    binaryHexMaxSize,
+		LanguageStats:       languageStats,
+		AnnotateLang:        annotateLang,
+		ExpandTabs:          expandTabWidth,
+		Reindent:            reindentWidth,
+		ListRelatedTests:    listRelatedTests,
+		GroupTests:          groupTests,
+		SortOrder:           sortOrder,
+		PathCase:            pathCase,
+		DryRun:              dryRun,
+		SkipDataFiles:       skipDataFiles,
+		DataFileRatio:       dataFileRatio,
+		JSONGitMeta:         jsonGitMeta && (outputFormat == "json" || outputFormat == "yaml"),
+	}
+
+	if printExcluded || excludedOut != "" || !quiet {
+		opts.Excluded = &exclusionLog{}
+	}
+
+	if contentLangs != "" {
+		opts.ContentLangs = parseLanguages(contentLangs)
+	}
+
+	if len(ignorePatterns) > 0 {
+		opts.Ignore = &ignoreMatcher{}
+		opts.Ignore.add("", ignorePatterns)
+	}
+	for _, r := range depthGlobRules {
+		rule, err := parseDepthRule(r)
+		if err != nil {
+			return opts, fmt.Errorf("invalid -depth-glob: %w", err)
+		}
+		opts.DepthRules = append(opts.DepthRules, rule)
+	}
+	for _, r := range langPreambles {
+		lang, note, err := parseLangPreamble(r)
+		if err != nil {
+			return opts, fmt.Errorf("invalid -lang-preamble: %w", err)
+		}
+		if opts.LangPreambles == nil {
+			opts.LangPreambles = make(map[string]string)
+		}
+		opts.LangPreambles[lang] = note
+	}
+	for _, r := range autoSkipRules {
+		rule, err := parseAutoSkipRule(r)
+		if err != nil {
+			return opts, fmt.Errorf("invalid -auto-skip-dir-if-matches: %w", err)
+		}
+		opts.AutoSkipDirs = append(opts.AutoSkipDirs, rule)
+	}
+	if stripBlankCode {
+		opts.StripBlankLines = make(map[string]bool)
+		for _, ext := range strings.Split(codeExtensions, ",") {
+			if ext = strings.ToLower(strings.TrimSpace(ext)); ext != "" {
+				opts.StripBlankLines[ext] = true
+			}
+		}
+	}
+	for _, marker := range strings.Split(denyContent, ",") {
+		if marker = strings.TrimSpace(marker); marker != "" {
+			opts.DenyContent = append(opts.DenyContent, marker)
+		}
+	}
+	if docAnchors {
+		opts.DocAnchors = []string{}
+		for _, name := range strings.Split(docFiles, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				opts.DocAnchors = append(opts.DocAnchors, name)
+			}
+		}
+	}
+	if includeDirs != "" {
+		dirs, err := parseIncludeDirs(rootDir, includeDirs)
+		if err != nil {
+			return opts, fmt.Errorf("invalid -dir: %w", err)
+		}
+		opts.IncludeDirs = dirs
+	}
+	for _, r := range replaceRules {
+		rule, err := parseReplaceRule(r, false)
+		if err != nil {
+			return opts, fmt.Errorf("invalid -replace: %w", err)
+		}
+		opts.Replacements = append(opts.Replacements, rule)
+	}
+	for _, r := range replaceRegexRules {
+		rule, err := parseReplaceRule(r, true)
+		if err != nil {
+			return opts, fmt.Errorf("invalid -replace-regex: %w", err)
+		}
+		opts.Replacements = append(opts.Replacements, rule)
+	}
+	for _, r := range headGlobRules {
+		rule, err := parseHeadRule(r)
+		if err != nil {
+			return opts, fmt.Errorf("invalid -head-glob: %w", err)
+		}
+		opts.HeadRules = append(opts.HeadRules, rule)
+	}
+	for _, r := range transformGlobs {
+		rule, err := parseTransformRule(r)
+		if err != nil {
+			return opts, fmt.Errorf("invalid -transform-glob: %w", err)
+		}
+		opts.Transforms = append(opts.Transforms, rule)
+	}
+	order, err := parseTransformOrder(transformOrder)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
-		os.Exit(1)
+		return opts, fmt.Errorf("invalid -transform-order: %w", err)
+	}
+	opts.TransformOrder = order
+	if useDockerignore {
+		lines, err := readIgnoreFile(filepath.Join(rootDir, ".dockerignore"))
+		if err != nil {
+			return opts, fmt.Errorf("reading .dockerignore: %w", err)
+		}
+		if opts.Ignore == nil {
+			opts.Ignore = &ignoreMatcher{}
+		}
+		opts.Ignore.add("", dockerignorePatterns(lines))
 	}
-	defer outFile.Close()
 
-	if err := writeDirectoryStructure(rootDir, excludeList, includeSizeLimit, sizeLimit, outFile); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing directory structure: %v\n", err)
-		os.Exit(1)
+	if _, ok := fileFormats[opts.Format]; !ok && !streamFormats[opts.Format] {
+		if opts.Format == "sqlite" {
+			return opts, fmt.Errorf("format sqlite is not available in this build (rebuild with -tags sqlite)")
+		}
+		return opts, fmt.Errorf("unknown output format %q (want txt, md, json, yaml, xml or tree-json)", opts.Format)
 	}
 
-	fmt.Printf("Successfully generated output to %s\n", outputFilename)
-}
+	switch runMode {
+	case "full":
+	case "overview":
+		// 概览只有纯文本形式，其他格式会被忽略
+		if opts.Format != "txt" {
+			return opts, fmt.Errorf("-mode overview only supports -format txt, not %s", opts.Format)
+		}
+		opts.Overview = true
+	default:
+		return opts, fmt.Errorf("unknown -mode %q (want full or overview)", runMode)
+	}
+
+	if outputFilename == stdoutName {
+		if _, ok := fileFormats[opts.Format]; ok {
+			return opts, fmt.Errorf("format %s cannot be written to stdout", opts.Format)
+		}
+		if skipIfUnchanged {
+			return opts, fmt.Errorf("-skip-if-unchanged needs an output file, not stdout")
+		}
+	} else if abs, err := filepath.Abs(outputFilename); err == nil {
+		opts.OutputPath = abs
+	}
+
+	if opts.SkipDataFiles && opts.DataFileRatio <= 0 {
+		return opts, fmt.Errorf("-data-file-ratio must be positive, got %v", opts.DataFileRatio)
+	}
+	if !sortOrders[opts.SortOrder] {
+		return opts, fmt.Errorf("unknown -sort order %q (want path, size-desc or size-asc)", opts.SortOrder)
+	}
+	if !pathCases[opts.PathCase] {
+		return opts, fmt.Errorf("unknown -normalize-paths-case %q (want preserve or lower)", opts.PathCase)
+	}
+
+	if err := applyRenderMode(&opts, renderMode, explicitFlags()); err != nil {
+		return opts, err
+	}
+
+	if excludeRegex != "" {
+		re, err := regexp.Compile(excludeRegex)
+		if err != nil {
+			return opts, fmt.Errorf("invalid -exclude-regex: %w", err)
+		}
+		opts.ExcludeRegex = re
+	}
+	for _, glob := range strings.Split(excludeGlobs, ",") {
+		if glob = strings.TrimSpace(glob); glob == "" {
+			continue
+		}
+		re, err := compileGlob(glob)
+		if err != nil {
+			return opts, fmt.Errorf("invalid -exclude-glob %q: %w", glob, err)
+		}
+		opts.ExcludeGlobs = append(opts.ExcludeGlobs, re)
+	}
+	if treeOnlyGlob != "" {
+		re, err := compileGlob(treeOnlyGlob)
+		if err != nil {
+			return opts, fmt.Errorf("invalid -tree-only-glob: %w", err)
+		}
+		opts.TreeOnlyGlob = re
+	}
 
-// isGitRoot 检查当前目录是否为 Git 仓库的根目录
-func isGitRoot() bool {
-	// 最简单的方法：检查是否存在 .git 目录
-	_, err := os.Stat(".git")
-	if err == nil {
-		return true // .git directory exists
+	if resumeManifest != "" {
+		prior, err := readManifestHashes(resumeManifest)
+		if err != nil {
+			return opts, fmt.Errorf("reading -resume manifest: %w", err)
+		}
+		opts.Resume = prior
+	}
+
+	if licenseSample != "" {
+		sample, err := os.ReadFile(licenseSample)
+		if err != nil {
+			return opts, fmt.Errorf("reading -license-header-file: %w", err)
+		}
+		opts.LicenseSample = normalizeComment(string(sample))
 	}
 
-	// 更严谨的方法：使用 git rev-parse --show-toplevel 命令 (更可靠，但稍慢)
+	if excludeOwner != "" {
+		owners, err := parseOwners(excludeOwner)
+		if err != nil {
+			return opts, err
+		}
+		opts.ExcludeOwners = owners
+	}
+
+	return opts, nil
+}
+
+// gitToplevel 通过 git rev-parse --show-toplevel 返回目录 dir 所在 Git 仓库的根目录，
+// 不在 Git 仓库中时返回错误
+func gitToplevel(dir string) (string, error) {
 	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	err = cmd.Run()
-	return err == nil // If the command runs successfully, we are in a git repo (possibly a subdirectory)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(strings.TrimSpace(string(out))), nil
 }
 
-func writeDirectoryStructure(rootDir string, excludeList map[string]bool, includeSizeLimit bool, sizeLimit int64, out io.Writer) error {
-	dirStructure, fileContents, err := buildDirectoryStructure(rootDir, excludeList, includeSizeLimit, sizeLimit)
+func writeDirectoryStructure(rootDir string, opts Options, out io.Writer) error {
+	tree, fileContents, err := ingest(rootDir, &opts)
 	if err != nil {
 		return err
 	}
-	return writeOutput(out, dirStructure, fileContents)
+	return render(out, tree, fileContents, opts)
 }
 
-func buildDirectoryStructure(rootDir string, excludeList map[string]bool, includeSizeLimit bool, sizeLimit int64) (string, map[string]string, error) {
-	var dirStructure strings.Builder
+// render 按 opts.Format 将收录结果写入 out
+func render(out io.Writer, tree *treeNode, fileContents map[string]string, opts Options) error {
+	if opts.Format == "tree-json" {
+		if opts.TreeOnlyGlob != nil {
+			tree = pruneTree(tree, opts.TreeOnlyGlob)
+		}
+		return writeTreeJSON(out, tree, opts.TreeRollup)
+	}
+	if opts.Overview {
+		return writeOverview(out, tree.name, renderTree(tree, opts), fileContents, opts)
+	}
+	if opts.Format == "json" {
+		ing := newIngest(renderTree(tree, opts), fileContents)
+		ing.addGitMeta(opts.GitMeta)
+		return writeJSON(out, ing)
+	}
+	if opts.Format == "xml" {
+		return writeXML(out, fileContents, opts)
+	}
+	if opts.Format == "yaml" {
+		ing := newIngest(renderTree(tree, opts), fileContents)
+		ing.addGitMeta(opts.GitMeta)
+		return writeYAML(out, ing)
+	}
+	if opts.Format == "md" {
+		return writeMarkdown(out, renderTree(tree, opts), fileContents, opts)
+	}
+	return writeOutput(out, renderTree(tree, opts), fileContents, opts)
+}
+
+// ingest 遍历目录收集文件，并完成清单、-resume、import 去重等与输出格式无关的处理，
+// 输出所需的 Git 历史存入 opts
+func ingest(rootDir string, opts *Options) (*treeNode, map[string]string, error) {
+	tree, fileContents, err := walkRepository(rootDir, *opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 清单记录收录的原始内容，以便下一次 -resume 继续使用，-verify 也与同样的快照比较；
+	// 超出输出预算的文件在写入前去掉
+	snapshot := (opts.ManifestPath != "" || opts.Verify) && !opts.DryRun
+	var m manifest
+	if snapshot {
+		m = buildManifest(fileContents, hashWorkers(*opts))
+	}
+	if opts.Resume != nil {
+		skipUnchanged(fileContents, opts.Resume)
+	}
+	if opts.DedupImports {
+		dedupImports(fileContents)
+	}
+	if opts.ListRelatedTests {
+		addRelatedTests(rootDir, fileContents)
+	}
+	skipped := applyBudgets(os.Stderr, fileContents, *opts)
+	// -dry-run 到此已确定收录的文件，不写清单，也不需要 Git 历史和路径转换
+	if opts.DryRun {
+		return tree, fileContents, nil
+	}
+	if snapshot {
+		opts.Snapshot = m.without(skipped)
+	}
+	if opts.ManifestPath != "" {
+		if err := writeManifest(opts.ManifestPath, opts.Snapshot); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := loadGitContext(rootDir, opts, fileContents); err != nil {
+		return nil, nil, fmt.Errorf("reading git history: %w", err)
+	}
+	// 读取磁盘和 Git 历史的步骤都使用原始路径，之后再转为小写
+	if opts.PathCase == "lower" {
+		lowerPathCase(tree, fileContents, opts)
+	}
+	if opts.PathComments {
+		for relPath, content := range fileContents {
+			if content != contentOmitted {
+				fileContents[relPath] = addPathComment(relPath, content)
+			}
+		}
+	}
+	return tree, fileContents, nil
+}
+
+func buildDirectoryStructure(rootDir string, opts Options) (string, map[string]string, error) {
+	tree, fileContents, err := walkRepository(rootDir, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	return renderTree(tree, opts), fileContents, nil
+}
+
+// walkRepository 遍历目录，返回目录树以及收录文件的 相对路径 -> 内容
+func walkRepository(rootDir string, opts Options) (*treeNode, map[string]string, error) {
+	tree := newTree(filepath.Base(rootDir))
+	dirNodes := map[string]*treeNode{".": tree}
 	fileContents := make(map[string]string)
+	var jobs []readJob
+
+	// 每次遍历使用独立的规则集：根目录 .gitignore 在前，命令行规则其次，子目录的 .gitignore 在遍历中追加，
+	// 后加入的规则优先
+	ignore := &ignoreMatcher{}
+	if opts.UseGitignore && !opts.NestedGitignoreOnly {
+		if err := ignore.addIgnoreFile(rootDir, ".gitignore", ""); err != nil {
+			return nil, nil, err
+		}
+	}
+	if opts.Ignore != nil {
+		ignore.patterns = append(ignore.patterns, opts.Ignore.patterns...)
+	}
+
+	// -follow-symlinks：记录已进入的符号链接目标（解析后的真实路径），每个目标只进入一次，避免循环
+	visitedLinks := map[string]bool{}
+	var realRoot string
+	if opts.FollowSymlinks {
+		var err error
+		if realRoot, err = filepath.EvalSymlinks(rootDir); err != nil {
+			return nil, nil, err
+		}
+		visitedLinks[realRoot] = true
+	}
 
-	err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+	var visit fs.WalkDirFunc
+	visit = func(path string, d fs.DirEntry, err error) error {
+		// 无法读取的目录或文件：默认警告并跳过，-strict 时中止
 		if err != nil {
-			return err
+			if opts.Strict || path == rootDir || d == nil {
+				return err
+			}
+			relPath, _ := filepath.Rel(rootDir, path)
+			warnUnreadable(relPath, err)
+			opts.Excluded.addUnreadable(relPath, d.IsDir(), err)
+			if d.IsDir() {
+				if node := dirNodes[relPath]; node != nil {
+					dirNodes[filepath.Dir(relPath)].removeChild(node)
+					delete(dirNodes, relPath)
+				}
+				return filepath.SkipDir
+			}
+			return nil
 		}
 
 		// 忽略隐藏目录及其内容
@@ -113,7 +1022,7 @@ func buildDirectoryStructure(rootDir string, excludeList map[string]bool, includ
 			return filepath.SkipDir
 		}
 
-		if d.IsDir() && (d.Name() == "node_modules" || d.Name() == "vendor") {
+		if d.IsDir() && opts.SkipDirs[d.Name()] {
 			return filepath.SkipDir
 		}
 
@@ -122,52 +1031,336 @@ func buildDirectoryStructure(rootDir string, excludeList map[string]bool, includ
 			return err
 		}
 
-		depth := strings.Count(relPath, string(os.PathSeparator))
-		indent := strings.Repeat("    ", depth)
+		if relPath == "." {
+			return nil
+		}
 
-		if d.IsDir() {
-			dirStructure.WriteString(fmt.Sprintf("%s%s/\n", indent, d.Name()))
-		} else {
-			ext := filepath.Ext(d.Name())
-			if excludeList[ext] {
+		// -dir：只进入限定目录及其上级目录，上级目录中的文件不收录
+		if opts.IncludeDirs != nil {
+			inside, ancestor := dirScope(relPath, opts.IncludeDirs)
+			switch {
+			case inside || (ancestor && d.IsDir()):
+			case d.IsDir():
+				return filepath.SkipDir
+			default:
 				return nil
 			}
+		}
+
+		if ignore.ignored(filepath.ToSlash(relPath), d.IsDir()) {
+			opts.Excluded.add(relPath, d.IsDir(), reasonGitignore)
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if depthExceeded(relPath, opts.DepthRules) {
+			opts.Excluded.add(relPath, d.IsDir(), reasonDepth)
+			if parent := dirNodes[filepath.Dir(relPath)]; parent != nil {
+				parent.truncated = true
+			}
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		parent := dirNodes[filepath.Dir(relPath)]
 
-			if includeSizeLimit {
-				info, err := d.Info()
+		// 符号链接：记录目标；指向目录（未开启 -follow-symlinks 或会形成循环时）或已失效的链接只出现在目录树中，不读取内容
+		var linkTarget string
+		if d.Type()&fs.ModeSymlink != 0 {
+			linkTarget, err = os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if info, err := os.Stat(path); err == nil && info.IsDir() && opts.FollowSymlinks {
+				follow, err := followLink(path, realRoot, opts.AllowOutside, visitedLinks)
 				if err != nil {
 					return err
 				}
-				if info.Size() > sizeLimit {
+				if follow {
+					// 以 / 结尾时 WalkDir 会解析链接，把它当作目录进入
+					if err := filepath.WalkDir(path+string(filepath.Separator), visit); err != nil {
+						return err
+					}
+					if node := dirNodes[relPath]; node != nil {
+						node.linkTarget = linkTarget
+					}
 					return nil
 				}
 			}
-			dirStructure.WriteString(fmt.Sprintf("%s%s\n", indent, d.Name())) //只写入目录结构
-			content, err := os.ReadFile(path)                                 //读取文件内容
+			if info, err := os.Stat(path); err != nil || info.IsDir() {
+				node := parent.addChild(d.Name(), relPath, false)
+				node.linkTarget = linkTarget
+				node.listOnly = true
+				return nil
+			}
+		}
+
+		if d.IsDir() {
+			// 目录下的所有路径都会匹配时（如 testdata/**）直接剪枝
+			if matchesAny(filepath.ToSlash(relPath)+"/", opts.ExcludeGlobs) {
+				opts.Excluded.add(relPath, true, reasonGlob)
+				return filepath.SkipDir
+			}
+			skip, err := autoSkipDir(path, opts.AutoSkipDirs)
+			if err != nil {
+				return err
+			}
+			if skip {
+				opts.Excluded.add(relPath, true, reasonAutoSkip)
+				return filepath.SkipDir
+			}
+			dirNodes[relPath] = parent.addChild(d.Name(), relPath, true)
+			if opts.UseGitignore {
+				if err := ignore.addIgnoreFile(path, ".gitignore", filepath.ToSlash(relPath)); err != nil {
+					return err
+				}
+			}
+		} else {
+			if isOutputFile(path, opts.OutputPath) {
+				opts.Excluded.add(relPath, false, reasonOwnOutput)
+				return nil
+			}
+
+			ext := filepath.Ext(d.Name())
+			info, err := d.Info()
+			if linkTarget != "" {
+				info, err = os.Stat(path) // 符号链接按其指向的文件判断
+			}
 			if err != nil {
 				return err
 			}
-			fileContents[relPath] = string(content) //将文件内容存入map
+			// -include-doc-anchors 的目录文档不受筛选条件限制，但仍遵守 .gitignore，内容也照常检查和变换
+			if !isDocAnchor(relPath, opts.DocAnchors) {
+				if reason := fileExclusion(relPath, ext, info, opts); reason != "" {
+					opts.Excluded.add(relPath, false, reason)
+					return nil
+				}
+			}
+			node := parent.addChild(d.Name(), relPath, false) //只写入目录结构
+			node.linkTarget = linkTarget
+			node.size = info.Size()
+			if opts.ContentLangs != nil && !opts.ContentLangs[strings.ToLower(detectLanguage(relPath))] {
+				fileContents[relPath] = contentOmitted
+				return nil
+			}
+			// 文件内容在遍历结束后并发读取
+			jobs = append(jobs, readJob{path: path, relPath: relPath, ext: ext, parent: parent, node: node})
 		}
 		return nil
-	})
+	}
+	err := filepath.WalkDir(rootDir, visit)
 
 	if err != nil {
-		return "", nil, err
+		return nil, nil, err
+	}
+	// 按遍历顺序处理读取结果，使目录树和排除报告与并发度无关
+	for i, r := range readFiles(jobs, opts) {
+		job := jobs[i]
+		switch {
+		case r.err != nil && opts.Strict:
+			return nil, nil, r.err
+		case r.err != nil:
+			job.parent.removeChild(job.node)
+			warnUnreadable(job.relPath, r.err)
+			opts.Excluded.addUnreadable(job.relPath, false, r.err)
+		case r.reason == reasonOwnOutput || r.reason == reasonData:
+			// 跳过本工具先前生成的结构化输出（如改名或移动过的 tree-json 文件）以及 -skip-data-files 识别出的数据文件
+			job.parent.removeChild(job.node)
+			opts.Excluded.add(job.relPath, false, r.reason)
+		case r.reason == reasonDenied:
+			// 含有 -deny-content 标记的文件既不收录内容，也不出现在目录树中
+			job.parent.removeChild(job.node)
+			opts.Excluded.addDetail(job.relPath, false, r.reason, "contains "+strconv.Quote(r.detail))
+		case r.reason == reasonBinary:
+			// 二进制文件只出现在目录树中
+			job.node.binary = true
+			opts.Excluded.add(job.relPath, false, r.reason)
+		default:
+			fileContents[job.relPath] = r.text //将文件内容存入map
+		}
 	}
 
-	return dirStructure.String(), fileContents, nil
+	return tree, fileContents, nil
 }
 
-func writeOutput(out io.Writer, dirStructure string, fileContents map[string]string) error {
-	io.WriteString(out, dirStructure)
-	io.WriteString(out, "\n")
-	for relPath, content := range fileContents {
-		io.WriteString(out, fmt.Sprintf("================================================\n"))
-		io.WriteString(out, fmt.Sprintf("File: %s\n", relPath))
-		io.WriteString(out, fmt.Sprintf("================================================\n"))
-		io.WriteString(out, content)
+// fileExclusion 按扩展名、路径、大小、属主等筛选条件判断文件是否排除，返回排除原因，收录时返回空字符串
+func fileExclusion(relPath, ext string, info fs.FileInfo, opts Options) string {
+	slashPath := filepath.ToSlash(relPath)
+	if (opts.IncludeList != nil && !opts.IncludeList[ext]) || opts.ExcludeList[ext] {
+		return reasonExtension
+	}
+	if opts.ExcludeRegex != nil && opts.ExcludeRegex.MatchString(slashPath) {
+		return reasonRegex
+	}
+	if matchesAny(slashPath, opts.ExcludeGlobs) {
+		return reasonGlob
+	}
+	if opts.Reachable != nil && !opts.Reachable[relPath] {
+		return reasonEntrypoint
+	}
+	if opts.IncludeSizeLimit && info.Size() > opts.SizeLimit {
+		return reasonSize
+	}
+	if uid, ok := fileOwner(info); ok && opts.ExcludeOwners[uid] {
+		return reasonOwner
+	}
+	if opts.OlderThan > 0 && time.Since(info.ModTime()) > opts.OlderThan {
+		return reasonOlderThan
+	}
+	if opts.ExcludeExecutable && info.Mode().Perm()&0111 != 0 {
+		return reasonExecutable
+	}
+	if opts.MaxPathLength > 0 && utf8.RuneCountInString(slashPath) > opts.MaxPathLength {
+		return reasonPathLength
+	}
+	return ""
+}
+
+// warnUnreadable 对无法读取而被跳过的路径输出警告
+func warnUnreadable(relPath string, err error) {
+	fmt.Fprintf(os.Stderr, "Warning: skipping unreadable %s: %v\n", filepath.ToSlash(relPath), err)
+}
+
+func writeOutput(out io.Writer, dirStructure string, fileContents map[string]string, opts Options) error {
+	paths := outputOrder(fileContents, opts)
+
+	if opts.StatsHeader {
+		writeStatsHeader(out, fileContents, "txt")
+	}
+	if len(opts.RecentCommits) > 0 {
+		io.WriteString(out, "Recent commits:\n")
+		for _, commit := range opts.RecentCommits {
+			io.WriteString(out, fmt.Sprintf("- %s\n", commit))
+		}
+		io.WriteString(out, "\n")
+	}
+	if opts.ShowMeta {
+		var total int
+		for _, content := range fileContents {
+			total += len(content)
+		}
+		io.WriteString(out, fmt.Sprintf("Files: %d\n", len(fileContents)))
+		io.WriteString(out, fmt.Sprintf("Total size: %d bytes\n\n", total))
+	}
+	if opts.ShowTree {
+		io.WriteString(out, dirStructure)
+		io.WriteString(out, "\n")
+	}
+	if opts.ShowTOC {
+		io.WriteString(out, "Table of contents:\n")
+		for _, relPath := range paths {
+			io.WriteString(out, fmt.Sprintf("- %s%s\n", relPath, sizeSuffix(fileContents[relPath], opts)))
+		}
+		io.WriteString(out, "\n")
+	}
+	// 按确定的顺序输出，保证相同输入得到相同输出（-skip-if-unchanged 依赖于此）
+	var smallFiles []string
+	seenLangs := make(map[string]bool)
+	for _, relPath := range paths {
+		content := fileContents[relPath]
+		if opts.MergeSmallFiles > 0 && len(content) < opts.MergeSmallFiles {
+			smallFiles = append(smallFiles, relPath)
+			continue
+		}
+		if note := preambleFor(relPath, content, opts.LangPreambles, seenLangs); note != "" {
+			io.WriteString(out, note+"\n\n")
+		}
+		writeFileHeader(out, fmt.Sprintf("File: %s%s%s", relPath, langSuffix(relPath, content, opts), sizeSuffix(content, opts)), opts)
+		io.WriteString(out, displayContent(content, opts))
 		io.WriteString(out, "\n\n")
 	}
+
+	// 小文件合并为一个块，每个文件以 --- path --- 分隔，节省横幅开销
+	if len(smallFiles) > 0 {
+		writeFileHeader(out, fmt.Sprintf("Small files (%d)", len(smallFiles)), opts)
+		for _, relPath := range smallFiles {
+			content := fileContents[relPath]
+			if note := preambleFor(relPath, content, opts.LangPreambles, seenLangs); note != "" {
+				io.WriteString(out, note+"\n")
+			}
+			io.WriteString(out, fmt.Sprintf("--- %s%s%s ---\n", relPath, langSuffix(relPath, content, opts), sizeSuffix(content, opts)))
+			io.WriteString(out, displayContent(content, opts))
+			if !strings.HasSuffix(content, "\n") {
+				io.WriteString(out, "\n")
+			}
+		}
+		io.WriteString(out, "\n")
+	}
 	return nil
 }
+
+// outputOrder 返回文件内容的输出顺序：按 -sort 排序，开启 -group-tests 时把测试文件移到源文件之后，
+// 开启 -include-doc-anchors 时再调整目录文档的位置
+func outputOrder(fileContents map[string]string, opts Options) []string {
+	paths := sortedPaths(fileContents, opts.SortOrder)
+	if opts.GroupTests {
+		paths = groupTestsWithSources(paths)
+	}
+	if opts.DocAnchors != nil {
+		paths = orderWithDocAnchors(paths, opts.DocAnchors)
+	}
+	return paths
+}
+
+// sortOrders 是 -sort 支持的取值
+var sortOrders = map[string]bool{"path": true, "size-desc": true, "size-asc": true}
+
+// sortedPaths 按 order 返回文件路径：path 按路径排序，size-desc/size-asc 按内容大小排序，
+// 大小相同时按路径排序
+func sortedPaths(fileContents map[string]string, order string) []string {
+	paths := make([]string, 0, len(fileContents))
+	for relPath := range fileContents {
+		paths = append(paths, relPath)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		a, b := len(fileContents[paths[i]]), len(fileContents[paths[j]])
+		switch {
+		case order == "size-desc" && a != b:
+			return a > b
+		case order == "size-asc" && a != b:
+			return a < b
+		}
+		return paths[i] < paths[j]
+	})
+	return paths
+}
+
+// displayContent 返回 txt 和 md 格式中写出的文件内容，开启 -line-numbers 时加上行号
+func displayContent(content string, opts Options) string {
+	if opts.LineNumbers && content != contentOmitted {
+		return numberLines(content)
+	}
+	return content
+}
+
+// writeFileHeader 写出文件块的标题行，开启 ShowBanners 时上下加 ==== 横幅
+func writeFileHeader(out io.Writer, title string, opts Options) {
+	if opts.ShowBanners {
+		io.WriteString(out, fmt.Sprintf("================================================\n"))
+	}
+	io.WriteString(out, title+"\n")
+	if opts.ShowBanners {
+		io.WriteString(out, fmt.Sprintf("================================================\n"))
+	}
+}
+
+// langSuffix 在开启 AnnotateLang 且能识别语言时返回形如 " (Go)" 的后缀
+func langSuffix(relPath, content string, opts Options) string {
+	if !opts.AnnotateLang {
+		return ""
+	}
+	if lang := detectContentLanguage(relPath, content); lang != "" {
+		return " (" + lang + ")"
+	}
+	return ""
+}
+
+// sizeSuffix 在开启 ShowSizes 时返回形如 " (123 bytes)" 的后缀
+func sizeSuffix(content string, opts Options) string {
+	if !opts.ShowSizes {
+		return ""
+	}
+	return fmt.Sprintf(" (%d bytes)", len(content))
+}
@@ -1,87 +1,96 @@
 package main
 
 import (
+	"bytes"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
-// TestIsGitRoot tests the isGitRoot function.
-func TestIsGitRoot(t *testing.T) {
+// TestGitToplevel tests the gitToplevel function.
+func TestGitToplevel(t *testing.T) {
 	// Create a temporary directory for testing.
 	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
 	if err != nil {
 		t.Fatalf("Failed to create temp dir: %v", err)
 	}
 	defer os.RemoveAll(tempDir) // Clean up after the test.
+	// Resolve symlinks (e.g. /tmp on macOS) so paths compare equal to git's output.
+	tempDir, err = filepath.EvalSymlinks(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
 
 	// Test cases:
 	tests := []struct {
 		name     string
-		setup    func(dir string) error // Function to set up the test environment
-		expected bool                   // Expected result
+		setup    func(dir string) (string, error) // Sets up the test environment, returns the directory to run in
+		expected string                           // Expected toplevel relative to the test directory, "" for an error
 	}{
 		{
 			name: "Not a Git repo",
-			setup: func(dir string) error {
-				return nil // No setup needed, just an empty directory.
+			setup: func(dir string) (string, error) {
+				return dir, nil // No setup needed, just an empty directory.
 			},
-			expected: false,
 		},
 		{
-			name: "Git repo (using .git directory)",
-			setup: func(dir string) error {
-				return os.Mkdir(filepath.Join(dir, ".git"), 0755)
+			name: "Bare .git directory is not a repo",
+			setup: func(dir string) (string, error) {
+				return dir, os.Mkdir(filepath.Join(dir, ".git"), 0755)
 			},
-			expected: true,
 		},
 		{
-			name: "Git repo (using git command, in root)",
-			setup: func(dir string) error {
+			name: "Git repo root",
+			setup: func(dir string) (string, error) {
 				// Initialize a Git repository in the temp directory.
 				cmd := exec.Command("git", "init")
 				cmd.Dir = dir
-				return cmd.Run()
+				return dir, cmd.Run()
 			},
-			expected: true,
+			expected: ".",
 		},
 		{
-			name: "Git repo (using git command, in subdirectory)",
-			setup: func(dir string) error {
+			name: "Git repo subdirectory",
+			setup: func(dir string) (string, error) {
 				// Initialize a Git repository.
 				cmd := exec.Command("git", "init")
 				cmd.Dir = dir
 				if err := cmd.Run(); err != nil {
-					return err
+					return "", err
 				}
-				// Create a subdirectory.
-				return os.Mkdir(filepath.Join(dir, "subdir"), 0755)
+				// Create a nested subdirectory to run in.
+				sub := filepath.Join(dir, "subdir", "deeper")
+				return sub, os.MkdirAll(sub, 0755)
 			},
-			expected: true, // Even in a subdirectory, it should return true.
+			expected: ".", // Even in a subdirectory, the repository root is returned.
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Change to the temporary directory for the test.
-			originalDir, err := os.Getwd()
-			if err != nil {
-				t.Fatalf("Failed to get current dir: %v", err)
-			}
 			testDir := filepath.Join(tempDir, tt.name)
-			os.MkdirAll(testDir, 0755)  // Create test directory
-			os.Chdir(testDir)           // Change to test directory.
-			defer os.Chdir(originalDir) // Restore original directory after the test.
-
-			if err := tt.setup(testDir); err != nil {
+			os.MkdirAll(testDir, 0755) // Create test directory
+			runDir, err := tt.setup(testDir)
+			if err != nil {
 				t.Fatalf("Setup failed: %v", err)
 			}
-
-			actual := isGitRoot()
-			if actual != tt.expected {
-				t.Errorf("isGitRoot() = %v, want %v", actual, tt.expected)
+			actual, err := gitToplevel(runDir)
+			if tt.expected == "" {
+				if err == nil {
+					t.Errorf("gitToplevel() = %q, want an error", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("gitToplevel() returned error: %v", err)
+			}
+			if want := filepath.Join(testDir, tt.expected); actual != want {
+				t.Errorf("gitToplevel() = %q, want %q", actual, want)
 			}
 		})
 	}
@@ -125,6 +134,7 @@ func TestBuildDirectoryStructure(t *testing.T) {
 		sizeLimit        int64
 		expectedFiles    []string // Expected file names (relative paths)
 		setup            func()
+		strict           bool
 		expectError      bool
 	}{
 		{
@@ -153,6 +163,7 @@ func TestBuildDirectoryStructure(t *testing.T) {
 				}
 			},
 			excludeList: map[string]bool{},
+			strict:      true,
 			expectError: true,
 		},
 	}
@@ -162,8 +173,17 @@ func TestBuildDirectoryStructure(t *testing.T) {
 			if tt.setup != nil {
 				tt.setup()
 			}
+			if tt.expectError && canReadFile(filepath.Join(tempDir, "unreadable.txt")) {
+				t.Skip("File permissions are not enforced (e.g. running as root)")
+			}
 
-			_, fileContents, err := buildDirectoryStructure(tempDir, tt.excludeList, tt.includeSizeLimit, tt.sizeLimit)
+			opts := Options{
+				ExcludeList:      tt.excludeList,
+				IncludeSizeLimit: tt.includeSizeLimit,
+				SizeLimit:        tt.sizeLimit,
+				Strict:           tt.strict,
+			}
+			_, fileContents, err := buildDirectoryStructure(tempDir, opts)
 
 			if tt.expectError {
 				if err == nil {
@@ -201,3 +221,386 @@ func TestBuildDirectoryStructure(t *testing.T) {
 		})
 	}
 }
+
+// TestOlderThan tests that files with old modification times are excluded.
+func TestOlderThan(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"fresh.txt": "recently edited",
+		"stale.txt": "untouched for ages",
+	})
+	old := time.Now().Add(-90 * 24 * time.Hour)
+	if err := os.Chtimes(filepath.Join(tempDir, "stale.txt"), old, old); err != nil {
+		t.Fatalf("Failed to set mtime: %v", err)
+	}
+
+	opts := Options{ExcludeList: map[string]bool{}, OlderThan: 30 * 24 * time.Hour}
+	_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if _, ok := fileContents["fresh.txt"]; !ok {
+		t.Error("fresh.txt should be included")
+	}
+	if _, ok := fileContents["stale.txt"]; ok {
+		t.Error("stale.txt should be excluded")
+	}
+}
+
+// TestIncludeExtensions tests the -include allowlist together with -exclude.
+func TestIncludeExtensions(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":      "package main",
+		"main_test.go": "package main",
+		"README.md":    "# readme",
+		"config.yaml":  "key: value",
+		"Makefile":     "all:",
+	})
+
+	tests := []struct {
+		name     string
+		include  map[string]bool
+		exclude  map[string]bool
+		expected []string
+	}{
+		{
+			name:     "Include only",
+			include:  map[string]bool{".go": true, ".md": true},
+			exclude:  map[string]bool{"": true},
+			expected: []string{"README.md", "main.go", "main_test.go"},
+		},
+		{
+			name:     "Exclude only",
+			exclude:  map[string]bool{"": true, ".md": true},
+			expected: []string{"config.yaml", "main.go", "main_test.go"},
+		},
+		{
+			name:     "Include then exclude",
+			include:  map[string]bool{".go": true, ".md": true},
+			exclude:  map[string]bool{"": true, ".md": true},
+			expected: []string{"main.go", "main_test.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{ExcludeList: tt.exclude, IncludeList: tt.include}
+			_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+			if err != nil {
+				t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+			}
+			if got := sortedPaths(fileContents, "path"); strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Included files = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSkipDirs tests skipping directories by name, with and without the built-in list.
+func TestSkipDirs(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":                "package main",
+		"vendor/lib/lib.go":      "package lib",
+		"web/node_modules/x.js":  "x",
+		"web/dist/bundle.js":     "bundle",
+		"web/src/dist.js":        "a file named like a skipped directory",
+		"pkg/dist/generated.txt": "generated",
+	})
+
+	tests := []struct {
+		name     string
+		skipDirs map[string]bool
+		expected []string
+	}{
+		{
+			name:     "Defaults",
+			skipDirs: map[string]bool{"node_modules": true, "vendor": true},
+			expected: []string{"main.go", "pkg/dist/generated.txt", "web/dist/bundle.js", "web/src/dist.js"},
+		},
+		{
+			name:     "Defaults plus dist",
+			skipDirs: map[string]bool{"node_modules": true, "vendor": true, "dist": true},
+			expected: []string{"main.go", "web/src/dist.js"},
+		},
+		{
+			name:     "No default skips",
+			skipDirs: map[string]bool{"dist": true},
+			expected: []string{"main.go", "vendor/lib/lib.go", "web/node_modules/x.js", "web/src/dist.js"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{ExcludeList: map[string]bool{}, SkipDirs: tt.skipDirs}
+			_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+			if err != nil {
+				t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+			}
+			var got []string
+			for _, relPath := range sortedPaths(fileContents, "path") {
+				got = append(got, filepath.ToSlash(relPath))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Included files = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestExcludeRegex tests excluding files by a regular expression over the relative path.
+func TestExcludeRegex(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":             "package main",
+		"pkg/test_helpers.go": "package pkg",
+		"pkg/mock_client.go":  "package pkg",
+		"pkg/client.go":       "package pkg",
+		"test_root.go":        "package main",
+	})
+
+	opts := Options{
+		ExcludeList:  map[string]bool{},
+		ExcludeRegex: regexp.MustCompile(`.*/(test|mock)_.*\.go$`),
+	}
+	_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	for _, path := range []string{"main.go", "pkg/client.go", "test_root.go"} {
+		if _, ok := fileContents[filepath.FromSlash(path)]; !ok {
+			t.Errorf("Expected file not found: %s", path)
+		}
+	}
+	for _, path := range []string{"pkg/test_helpers.go", "pkg/mock_client.go"} {
+		if _, ok := fileContents[filepath.FromSlash(path)]; ok {
+			t.Errorf("File should be excluded by regex: %s", path)
+		}
+	}
+}
+
+// TestExcludeExecutable tests excluding files by their executable permission bits.
+func TestExcludeExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Permission bits are not supported on Windows")
+	}
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := os.WriteFile(filepath.Join(tempDir, "build.sh"), []byte("#!/bin/sh"), 0755); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "notes.txt"), []byte("notes"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	opts := Options{ExcludeList: map[string]bool{}, ExcludeExecutable: true}
+	_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if _, ok := fileContents["build.sh"]; ok {
+		t.Error("build.sh (0755) should be excluded")
+	}
+	if _, ok := fileContents["notes.txt"]; !ok {
+		t.Error("notes.txt (0644) should be kept")
+	}
+}
+
+// canReadFile reports whether path can be read, which is the case for any file when running as root.
+func canReadFile(path string) bool {
+	_, err := os.ReadFile(path)
+	return err == nil
+}
+
+// TestUnreadableFile tests that unreadable files are skipped with their error unless -strict is set.
+func TestUnreadableFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{"main.go": "package main", "locked/secret.txt": "secret"})
+	if err := os.WriteFile(filepath.Join(tempDir, "unreadable.txt"), []byte("unreadable"), 0222); err != nil {
+		t.Fatalf("Failed to create unreadable file: %v", err)
+	}
+	if err := os.Chmod(filepath.Join(tempDir, "locked"), 0); err != nil {
+		t.Fatalf("Failed to lock directory: %v", err)
+	}
+	defer os.Chmod(filepath.Join(tempDir, "locked"), 0755) // Let RemoveAll clean up.
+	if canReadFile(filepath.Join(tempDir, "unreadable.txt")) {
+		t.Skip("File permissions are not enforced (e.g. running as root)")
+	}
+
+	opts := Options{ExcludeList: map[string]bool{}, Excluded: &exclusionLog{}}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if len(fileContents) != 1 || fileContents["main.go"] != "package main" {
+		t.Errorf("Only main.go should be included, got %v", fileContents)
+	}
+	if containsLine(tree, "unreadable.txt") || containsLine(tree, "locked/") {
+		t.Errorf("Unreadable paths should not be listed in the tree:\n%s", tree)
+	}
+	var report strings.Builder
+	opts.Excluded.write(&report)
+	for _, prefix := range []string{"locked/\tunreadable\t", "unreadable.txt\tunreadable\t"} {
+		if !strings.Contains(report.String(), prefix) {
+			t.Errorf("Report is missing %q:\n%s", prefix, report.String())
+		}
+	}
+
+	opts = Options{ExcludeList: map[string]bool{}, Strict: true}
+	if _, _, err := buildDirectoryStructure(tempDir, opts); err == nil {
+		t.Error("Expected an error with Strict, but got nil")
+	}
+}
+
+// TestMaxPathLength tests skipping files whose relative path is too long.
+func TestMaxPathLength(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	longPath := strings.Repeat("deeply_nested_directory/", 8) + "file.go"
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":  "package main",
+		"pkg/a.go": "package pkg",
+		longPath:   "package deep",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, MaxPathLength: 40, Excluded: &exclusionLog{}}
+	_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if _, ok := fileContents[filepath.FromSlash(longPath)]; ok {
+		t.Errorf("%s should be skipped", longPath)
+	}
+	for _, path := range []string{"main.go", "pkg/a.go"} {
+		if _, ok := fileContents[filepath.FromSlash(path)]; !ok {
+			t.Errorf("Expected file not found: %s", path)
+		}
+	}
+	if len(opts.Excluded.entries) != 1 || opts.Excluded.entries[0].Reason != reasonPathLength {
+		t.Errorf("Unexpected exclusions: %v", opts.Excluded.entries)
+	}
+}
+
+// TestExcludeGlob tests excluding files by globs over the repo-relative path.
+func TestExcludeGlob(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":                "package main",
+		"api/types.generated.go": "package api",
+		"api/types.go":           "package api",
+		"testdata/a/case.json":   "{}",
+		"docs/guide.md":          "guide",
+		"docs/api/ref.md":        "ref",
+		"README.md":              "readme",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}}
+	for _, glob := range []string{"testdata/**", "*.generated.go", "docs/*.md"} {
+		re, err := compileGlob(glob)
+		if err != nil {
+			t.Fatalf("compileGlob(%q) returned error: %v", glob, err)
+		}
+		opts.ExcludeGlobs = append(opts.ExcludeGlobs, re)
+	}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	for _, path := range []string{"main.go", "api/types.go", "docs/api/ref.md", "README.md"} {
+		if _, ok := fileContents[filepath.FromSlash(path)]; !ok {
+			t.Errorf("Expected file not found: %s", path)
+		}
+	}
+	for _, path := range []string{"api/types.generated.go", "testdata/a/case.json", "docs/guide.md"} {
+		if _, ok := fileContents[filepath.FromSlash(path)]; ok {
+			t.Errorf("File should be excluded by glob: %s", path)
+		}
+	}
+	if containsLine(tree, "testdata/") {
+		t.Errorf("Fully excluded directory should be pruned from the tree:\n%s", tree)
+	}
+}
+
+// TestDeterministicOutput tests that two runs over the same tree produce identical bytes,
+// with files and tree entries in sorted order.
+func TestDeterministicOutput(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{}
+	for _, name := range []string{"zeta", "alpha", "mid", "beta", "omega", "gamma", "delta", "kappa"} {
+		files[name+".go"] = "package " + name
+		files[filepath.Join("pkg", name, name+".go")] = "package " + name
+	}
+	writeTestFiles(t, tempDir, files)
+
+	opts := Options{ExcludeList: map[string]bool{}, ShowTree: true, ShowBanners: true, ShowTOC: true}
+	var first bytes.Buffer
+	if err := writeDirectoryStructure(tempDir, opts, &first); err != nil {
+		t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		var again bytes.Buffer
+		if err := writeDirectoryStructure(tempDir, opts, &again); err != nil {
+			t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+		}
+		if !bytes.Equal(first.Bytes(), again.Bytes()) {
+			t.Fatalf("Output differs between runs:\n%s\n---\n%s", first.String(), again.String())
+		}
+	}
+
+	s := first.String()
+	if !(strings.Index(s, "File: alpha.go") < strings.Index(s, "File: beta.go") &&
+		strings.Index(s, "File: beta.go") < strings.Index(s, "File: zeta.go")) {
+		t.Errorf("Files are not in sorted order:\n%s", s)
+	}
+	tree := s[:strings.Index(s, "Table of contents:")]
+	if !(strings.Index(tree, "── alpha/") < strings.Index(tree, "── kappa/") &&
+		strings.Index(tree, "── kappa/") < strings.Index(tree, "── zeta/")) {
+		t.Errorf("Tree entries are not sorted within their directory:\n%s", tree)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// manifestEntry 记录一个收录文件的路径、大小与内容哈希
+type manifestEntry struct {
+	Path   string `json:"path"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// manifest 描述一次运行收录的全部文件，可供后续运行 -resume 使用
+type manifest struct {
+	Files []manifestEntry `json:"files"`
+}
+
+// contentHash 返回内容的 SHA-256 十六进制摘要
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// buildManifest 按路径排序生成清单，路径统一使用 / 分隔。workers 大于 1 时（-parallel-hash）
+// 并发计算各文件的哈希，结果与依次计算相同。
+func buildManifest(fileContents map[string]string, workers int) manifest {
+	relPaths := make([]string, 0, len(fileContents))
+	for relPath := range fileContents {
+		relPaths = append(relPaths, relPath)
+	}
+	sort.Slice(relPaths, func(i, j int) bool { return filepath.ToSlash(relPaths[i]) < filepath.ToSlash(relPaths[j]) })
+
+	m := manifest{Files: make([]manifestEntry, len(relPaths))}
+	runPool(workers, len(relPaths), func(i int) {
+		content := fileContents[relPaths[i]]
+		m.Files[i] = manifestEntry{
+			Path:   filepath.ToSlash(relPaths[i]),
+			Size:   len(content),
+			SHA256: contentHash(content),
+		}
+	})
+	return m
+}
+
+// without 返回去掉 paths（以 / 分隔）中各文件后的清单
+func (m manifest) without(paths map[string]bool) manifest {
+	kept := manifest{Files: make([]manifestEntry, 0, len(m.Files))}
+	for _, f := range m.Files {
+		if !paths[f.Path] {
+			kept.Files = append(kept.Files, f)
+		}
+	}
+	return kept
+}
+
+// hashWorkers 返回计算文件哈希使用的 goroutine 数：开启 -parallel-hash 时与读取文件相同，否则为 1
+func hashWorkers(opts Options) int {
+	if opts.ParallelHash {
+		return poolSize(opts.Jobs)
+	}
+	return 1
+}
+
+// repoDigest 返回整个收录集合的摘要：对按路径排序的 "路径 哈希" 行再做一次 SHA-256，
+// 相同的文件集合与内容总是得到相同的摘要
+func repoDigest(fileContents map[string]string, workers int) string {
+	h := sha256.New()
+	for _, f := range buildManifest(fileContents, workers).Files {
+		fmt.Fprintf(h, "%s %s\n", f.Path, f.SHA256)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// duplicateGroups 按内容哈希对文件分组，返回含两个及以上文件的组。
+// 组内路径排序，各组按首个路径排序。
+func duplicateGroups(fileContents map[string]string) [][]string {
+	byHash := make(map[string][]string)
+	for _, f := range buildManifest(fileContents, 1).Files {
+		byHash[f.SHA256] = append(byHash[f.SHA256], f.Path)
+	}
+	var groups [][]string
+	for _, paths := range byHash {
+		if len(paths) > 1 {
+			groups = append(groups, paths)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+	return groups
+}
+
+// printDuplicates 输出内容完全相同的文件组
+func printDuplicates(w io.Writer, fileContents map[string]string) {
+	groups := duplicateGroups(fileContents)
+	fmt.Fprintf(w, "Duplicate files: %d groups\n", len(groups))
+	for _, paths := range groups {
+		fmt.Fprintf(w, "  %s (%d bytes)\n", strings.Join(paths, ", "), len(fileContents[filepath.FromSlash(paths[0])]))
+	}
+}
+
+func writeManifest(path string, m manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// readManifestHashes 读取清单，返回 路径 -> 哈希
+func readManifestHashes(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	hashes := make(map[string]string, len(m.Files))
+	for _, f := range m.Files {
+		hashes[f.Path] = f.SHA256
+	}
+	return hashes, nil
+}
+
+// exitDrift 是 -verify 发现与清单不一致时使用的退出码
+const exitDrift = 4
+
+// drift 是当前文件集合与清单之间的差异，路径以 / 分隔并排序
+type drift struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+func (d drift) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// diffManifest 比较当前的清单与先前清单中的 路径 -> 哈希
+func diffManifest(current manifest, prior map[string]string) drift {
+	var d drift
+	seen := make(map[string]bool, len(current.Files))
+	for _, f := range current.Files {
+		seen[f.Path] = true
+		hash, ok := prior[f.Path]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, f.Path)
+		case hash != f.SHA256:
+			d.Changed = append(d.Changed, f.Path)
+		}
+	}
+	for path := range prior {
+		if !seen[path] {
+			d.Removed = append(d.Removed, path)
+		}
+	}
+	sort.Strings(d.Removed)
+	return d
+}
+
+// writeDrift 逐行输出差异，如 "changed: main.go"
+func writeDrift(w io.Writer, d drift) {
+	for _, path := range d.Added {
+		fmt.Fprintf(w, "added: %s\n", path)
+	}
+	for _, path := range d.Removed {
+		fmt.Fprintf(w, "removed: %s\n", path)
+	}
+	for _, path := range d.Changed {
+		fmt.Fprintf(w, "changed: %s\n", path)
+	}
+}
+
+// skipUnchanged 从 fileContents 中删除路径与哈希都和先前清单一致的文件
+func skipUnchanged(fileContents map[string]string, prior map[string]string) {
+	for relPath, content := range fileContents {
+		if hash, ok := prior[filepath.ToSlash(relPath)]; ok && hash == contentHash(content) {
+			delete(fileContents, relPath)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestResumeFromManifest tests that a resumed run emits only new and changed files.
+func TestResumeFromManifest(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	repoDir := filepath.Join(tempDir, "repo")
+	manifestFile := filepath.Join(tempDir, "manifest.json")
+
+	writeTestFiles(t, repoDir, map[string]string{
+		"same.txt":    "unchanged content",
+		"changed.txt": "old content",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, ShowBanners: true, ManifestPath: manifestFile}
+	var first bytes.Buffer
+	if err := writeDirectoryStructure(repoDir, opts, &first); err != nil {
+		t.Fatalf("First run returned error: %v", err)
+	}
+
+	writeTestFiles(t, repoDir, map[string]string{
+		"changed.txt": "new content",
+		"added.txt":   "brand new file",
+	})
+	prior, err := readManifestHashes(manifestFile)
+	if err != nil {
+		t.Fatalf("readManifestHashes() returned error: %v", err)
+	}
+	if len(prior) != 2 {
+		t.Fatalf("Manifest has %d entries, want 2", len(prior))
+	}
+
+	opts = Options{ExcludeList: map[string]bool{}, ShowBanners: true, Resume: prior}
+	var second bytes.Buffer
+	if err := writeDirectoryStructure(repoDir, opts, &second); err != nil {
+		t.Fatalf("Resumed run returned error: %v", err)
+	}
+	got := second.String()
+	for _, want := range []string{"File: changed.txt", "new content", "File: added.txt"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Resumed output is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "File: same.txt") {
+		t.Errorf("Resumed output should skip unchanged same.txt:\n%s", got)
+	}
+}
+
+// TestManifestBudgets tests that files dropped by -max-tokens or -max-output are left out of the manifest.
+func TestManifestBudgets(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	repoDir := filepath.Join(tempDir, "repo")
+	manifestFile := filepath.Join(tempDir, "manifest.json")
+
+	writeTestFiles(t, repoDir, map[string]string{
+		"a.txt": strings.Repeat("a", 40),
+		"b.txt": strings.Repeat("b", 40),
+		"c.txt": strings.Repeat("c", 40),
+	})
+
+	for _, opts := range []Options{
+		{ExcludeList: map[string]bool{}, ManifestPath: manifestFile, MaxTokens: 15},
+		{ExcludeList: map[string]bool{}, ManifestPath: manifestFile, MaxOutput: 60},
+	} {
+		var out bytes.Buffer
+		if err := writeDirectoryStructure(repoDir, opts, &out); err != nil {
+			t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+		}
+		prior, err := readManifestHashes(manifestFile)
+		if err != nil {
+			t.Fatalf("readManifestHashes() returned error: %v", err)
+		}
+		if _, ok := prior["a.txt"]; !ok || len(prior) != 1 {
+			t.Errorf("Manifest = %v, want only a.txt (max-tokens %d, max-output %d)", prior, opts.MaxTokens, opts.MaxOutput)
+		}
+		if strings.Contains(out.String(), "bbbb") {
+			t.Errorf("b.txt should be left out of the output:\n%s", out.String())
+		}
+	}
+}
+
+// TestRepoDigest tests that the digest is stable across runs and changes with any file.
+func TestRepoDigest(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":       "package main",
+		"pkg/a.go":      "package pkg",
+		"pkg/b.go":      "package pkg",
+		"docs/guide.md": "guide",
+	})
+	digest := func() string {
+		_, fileContents, err := buildDirectoryStructure(tempDir, Options{ExcludeList: map[string]bool{}})
+		if err != nil {
+			t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+		}
+		return repoDigest(fileContents, 1)
+	}
+
+	first := digest()
+	if again := digest(); again != first {
+		t.Errorf("Digest changed between identical runs: %s != %s", first, again)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "pkg", "b.go"), []byte("package pkg // changed"), 0644); err != nil {
+		t.Fatalf("Failed to modify file: %v", err)
+	}
+	if changed := digest(); changed == first {
+		t.Error("Digest should change when a file's content changes")
+	}
+}
+
+// TestVerifyManifest tests detecting added, removed and changed files against a manifest.
+func TestVerifyManifest(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":  "package main",
+		"pkg/a.go": "package pkg",
+		"old.go":   "package main",
+	})
+	opts := Options{ExcludeList: map[string]bool{}}
+	_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	manifestPath := filepath.Join(tempDir, "manifest.json")
+	if err := writeManifest(manifestPath, buildManifest(fileContents, 1)); err != nil {
+		t.Fatalf("writeManifest() returned error: %v", err)
+	}
+	prior, err := readManifestHashes(manifestPath)
+	if err != nil {
+		t.Fatalf("readManifestHashes() returned error: %v", err)
+	}
+	if d := diffManifest(buildManifest(fileContents, 1), prior); !d.empty() {
+		t.Errorf("Unmodified repository should not drift: %+v", d)
+	}
+
+	os.Remove(manifestPath)
+	os.Remove(filepath.Join(tempDir, "old.go"))
+	writeTestFiles(t, tempDir, map[string]string{
+		"pkg/a.go": "package pkg // changed",
+		"new.go":   "package main",
+	})
+	_, fileContents, err = buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	d := diffManifest(buildManifest(fileContents, 1), prior)
+
+	var out bytes.Buffer
+	writeDrift(&out, d)
+	want := "added: new.go\nremoved: old.go\nchanged: pkg/a.go\n"
+	if out.String() != want {
+		t.Errorf("Drift report = %q, want %q", out.String(), want)
+	}
+}
+
+// TestVerifyAfterRewrites tests that -verify hashes the same snapshot as -manifest, before content rewrites.
+func TestVerifyAfterRewrites(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	repoDir := filepath.Join(tempDir, "repo")
+	manifestPath := filepath.Join(tempDir, "manifest.json")
+
+	imports := "import (\n\t\"fmt\"\n\t\"os\"\n)\n"
+	writeTestFiles(t, repoDir, map[string]string{
+		"main.go":         "package main\n",
+		"internal/one.go": "package internal\n\n" + imports + "\nfunc One() { fmt.Println(os.Args) }\n",
+		"internal/two.go": "package internal\n\n" + imports + "\nfunc Two() { fmt.Println(os.Args) }\n",
+		"docs/README.md":  "# Docs\n",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, PathComments: true, DedupImports: true, ManifestPath: manifestPath}
+	var out bytes.Buffer
+	if err := writeDirectoryStructure(repoDir, opts, &out); err != nil {
+		t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+	}
+	prior, err := readManifestHashes(manifestPath)
+	if err != nil {
+		t.Fatalf("readManifestHashes() returned error: %v", err)
+	}
+
+	opts = Options{ExcludeList: map[string]bool{}, PathComments: true, DedupImports: true, Verify: true}
+	if _, _, err := ingest(repoDir, &opts); err != nil {
+		t.Fatalf("ingest() returned error: %v", err)
+	}
+	if d := diffManifest(opts.Snapshot, prior); !d.empty() {
+		t.Errorf("Unmodified repository should not drift: %+v", d)
+	}
+}
+
+// TestDuplicateGroups tests grouping files with identical content.
+func TestDuplicateGroups(t *testing.T) {
+	fileContents := map[string]string{
+		filepath.FromSlash("a/util.go"):         "package util\n",
+		filepath.FromSlash("b/util_copy.go"):    "package util\n",
+		"LICENSE.txt":                           "MIT",
+		filepath.FromSlash("third/LICENSE.txt"): "MIT",
+		"main.go":                               "package main\n",
+	}
+	groups := duplicateGroups(fileContents)
+	want := [][]string{{"LICENSE.txt", "third/LICENSE.txt"}, {"a/util.go", "b/util_copy.go"}}
+	if len(groups) != len(want) {
+		t.Fatalf("Got %d groups, want %d: %v", len(groups), len(want), groups)
+	}
+	for i := range want {
+		if strings.Join(groups[i], " ") != strings.Join(want[i], " ") {
+			t.Errorf("Group %d = %v, want %v", i, groups[i], want[i])
+		}
+	}
+
+	var out bytes.Buffer
+	printDuplicates(&out, fileContents)
+	if !strings.Contains(out.String(), "a/util.go, b/util_copy.go (13 bytes)") {
+		t.Errorf("Unexpected report:\n%s", out.String())
+	}
+}
+
+// TestParallelHash tests that hashing with several workers gives the same manifest and digest as the serial computation.
+func TestParallelHash(t *testing.T) {
+	fileContents := make(map[string]string)
+	for i := 0; i < 500; i++ {
+		fileContents[filepath.Join(fmt.Sprintf("pkg%d", i%13), fmt.Sprintf("file%d.go", i))] = strings.Repeat(fmt.Sprintf("line %d\n", i), i%50)
+	}
+
+	serial := buildManifest(fileContents, 1)
+	for _, workers := range []int{2, 8} {
+		if got := buildManifest(fileContents, workers); !reflect.DeepEqual(got, serial) {
+			t.Errorf("Manifest with %d workers differs from the serial one", workers)
+		}
+		if got, want := repoDigest(fileContents, workers), repoDigest(fileContents, 1); got != want {
+			t.Errorf("Digest with %d workers = %s, want %s", workers, got, want)
+		}
+	}
+}
+
+// BenchmarkDigest compares serial and parallel hashing of a few thousand files.
+func BenchmarkDigest(b *testing.B) {
+	fileContents := make(map[string]string)
+	for i := 0; i < 3000; i++ {
+		fileContents[fmt.Sprintf("pkg%d/file%d.go", i%20, i)] = strings.Repeat("// filler line for a synthetic file\n", 400)
+	}
+	for _, workers := range []int{1, max(2, runtime.NumCPU())} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				repoDigest(fileContents, workers)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// fenceLang 返回 Markdown 代码块的语言标记（如 go、py），取自已知语言的扩展名，未知时为空
+func fenceLang(relPath string) string {
+	ext := strings.ToLower(filepath.Ext(relPath))
+	if _, ok := languages[ext]; !ok {
+		return ""
+	}
+	return strings.TrimPrefix(ext, ".")
+}
+
+// fenceFor 返回能包住 content 的代码块围栏：比内容中最长的连续反引号多一个，至少三个，
+// 且不短于 minSize
+func fenceFor(content string, minSize int) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, minSize, longest+1))
+}
+
+// writeFenced 将 content 写成一个代码块
+func writeFenced(out io.Writer, lang, content string, minSize int) {
+	fence := fenceFor(content, minSize)
+	io.WriteString(out, fence+lang+"\n")
+	io.WriteString(out, content)
+	if !strings.HasSuffix(content, "\n") {
+		io.WriteString(out, "\n")
+	}
+	io.WriteString(out, fence+"\n\n")
+}
+
+// writeMarkdown 以 Markdown 输出：目录树放在代码块中，每个文件是一个 "## 路径" 小节加代码块
+func writeMarkdown(out io.Writer, dirStructure string, fileContents map[string]string, opts Options) error {
+	paths := outputOrder(fileContents, opts)
+
+	if opts.StatsHeader {
+		writeStatsHeader(out, fileContents, "md")
+	}
+	if len(opts.RecentCommits) > 0 {
+		io.WriteString(out, "## Recent commits\n\n")
+		for _, commit := range opts.RecentCommits {
+			fmt.Fprintf(out, "- %s\n", commit)
+		}
+		io.WriteString(out, "\n")
+	}
+	if opts.ShowMeta {
+		var total int
+		for _, content := range fileContents {
+			total += len(content)
+		}
+		fmt.Fprintf(out, "Files: %d  \nTotal size: %d bytes\n\n", len(fileContents), total)
+	}
+	if opts.ShowTree {
+		io.WriteString(out, "## Directory structure\n\n")
+		writeFenced(out, "", dirStructure, opts.FenceSize)
+	}
+	if opts.ShowTOC {
+		io.WriteString(out, "## Table of contents\n\n")
+		for _, relPath := range paths {
+			fmt.Fprintf(out, "- %s%s\n", filepath.ToSlash(relPath), sizeSuffix(fileContents[relPath], opts))
+		}
+		io.WriteString(out, "\n")
+	}
+	seenLangs := make(map[string]bool)
+	for _, relPath := range paths {
+		content := fileContents[relPath]
+		if note := preambleFor(relPath, content, opts.LangPreambles, seenLangs); note != "" {
+			io.WriteString(out, note+"\n\n")
+		}
+		fmt.Fprintf(out, "## %s%s%s\n\n", filepath.ToSlash(relPath), langSuffix(relPath, content, opts), sizeSuffix(content, opts))
+		writeFenced(out, fenceLang(relPath), displayContent(content, opts), opts.FenceSize)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestMarkdownFormat tests the md output layout and fence languages.
+func TestMarkdownFormat(t *testing.T) {
+	fileContents := map[string]string{
+		"main.go":        "package main\n",
+		"scripts/run.py": "print('hi')\n",
+		"notes.unknown":  "plain",
+		"docs/fences.md": "Example:\n```go\nx := 1\n```\n",
+	}
+	var out bytes.Buffer
+	tree := "repo/\nmain.go\n"
+	if err := writeMarkdown(&out, tree, fileContents, Options{ShowTree: true}); err != nil {
+		t.Fatalf("writeMarkdown() returned error: %v", err)
+	}
+	s := out.String()
+
+	for _, want := range []string{
+		"## Directory structure\n\n```\nrepo/\nmain.go\n```\n",
+		"## main.go\n\n```go\npackage main\n```\n",
+		"## scripts/run.py\n\n```py\nprint('hi')\n```\n",
+		"## notes.unknown\n\n```\nplain\n```\n",
+		// Content containing a fence gets a longer one.
+		"## docs/fences.md\n\n````md\nExample:\n```go\nx := 1\n```\n````\n",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("Markdown output is missing %q:\n%s", want, s)
+		}
+	}
+	if strings.Contains(s, "====") || strings.Contains(s, "File: ") {
+		t.Errorf("Markdown output should not contain text-format banners:\n%s", s)
+	}
+}
+
+// TestFenceSize tests that -fence-size sets the minimum fence length for all blocks.
+func TestFenceSize(t *testing.T) {
+	fileContents := map[string]string{
+		"main.go":        "package main\n",
+		"docs/fences.md": "``````\nsix backticks\n``````\n",
+	}
+	var out bytes.Buffer
+	if err := writeMarkdown(&out, "repo/\n", fileContents, Options{ShowTree: true, FenceSize: 5}); err != nil {
+		t.Fatalf("writeMarkdown() returned error: %v", err)
+	}
+	s := out.String()
+
+	for _, want := range []string{
+		"## Directory structure\n\n`````\nrepo/\n`````\n",
+		"## main.go\n\n`````go\npackage main\n`````\n",
+		// Content with a longer backtick run still gets a longer fence.
+		"## docs/fences.md\n\n```````md\n``````\nsix backticks\n``````\n```````\n",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("Markdown output is missing %q:\n%s", want, s)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// exitUnchanged 是 -skip-if-unchanged 时输出未变化所使用的退出码
+const exitUnchanged = 3
+
+// schemaPrefix 是 tree-json 输出中 schema 标记的前缀
+const schemaPrefix = "local-gitingest/"
+
+// ownOutputSniffLen 是识别本工具输出时查看的文件开头字节数
+const ownOutputSniffLen = 512
+
+// isOwnOutput 判断内容是否为本工具先前生成的结构化输出：tree-json 按开头的 treeJSONSchema 标记识别；
+// json、yaml 和 xml 按各自格式的固定结构识别，只是提到 local-gitingest/ 或键名相似的文件不算
+func isOwnOutput(content []byte) bool {
+	head := bytes.TrimSpace(content[:min(len(content), ownOutputSniffLen)])
+	if rest, ok := bytes.CutPrefix(head, []byte("{")); ok {
+		rest = bytes.TrimSpace(rest)
+		if bytes.HasPrefix(rest, []byte(`"$schema": "`+treeJSONSchema+`"`)) {
+			return true
+		}
+		return bytes.HasPrefix(rest, []byte(`"tree":`)) && isIngestJSON(content)
+	}
+	if bytes.HasPrefix(head, []byte("tree: ")) {
+		return isIngestYAML(content)
+	}
+	if rest, ok := bytes.CutPrefix(head, []byte(xmlHeader)); ok {
+		return bytes.HasPrefix(rest, []byte("<document index=\"1\">\n<source>")) || bytes.Equal(rest, []byte("</documents>"))
+	}
+	return false
+}
+
+// isOutputFile 判断遍历到的 path 是否就是输出文件 outputPath。两者可能经由不同的符号链接到达
+// （如从链接目录运行时，仓库根目录是解析后的真实路径），因此按文件本身而不是路径字符串比较。
+func isOutputFile(path, outputPath string) bool {
+	if outputPath == "" || filepath.Base(path) != filepath.Base(outputPath) {
+		return false
+	}
+	if path == outputPath {
+		return true
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	out, err := os.Stat(outputPath)
+	return err == nil && os.SameFile(fi, out)
+}
+
+// writeIfChanged 仅当 data 与 path 的现有内容哈希不同时才写入文件，返回是否发生了写入
+func writeIfChanged(path string, data []byte) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err == nil {
+		oldSum, newSum := sha256.Sum256(existing), sha256.Sum256(data)
+		if bytes.Equal(oldSum[:], newSum[:]) {
+			return false, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+	return true, os.WriteFile(path, data, 0644)
+}
+
+// gzipOutput 判断是否以 gzip 压缩输出：指定了 -compress，或输出文件名以 .gz 结尾
+func gzipOutput(path string, force bool) bool {
+	return force || (path != stdoutName && strings.HasSuffix(strings.ToLower(path), ".gz"))
+}
+
+// renderCompressed 与 render 相同，但 compressed 时写出 gzip 压缩后的输出。
+// gzip.Writer 在返回前关闭，以写出缓冲的数据和 gzip 尾部。
+func renderCompressed(out io.Writer, tree *treeNode, fileContents map[string]string, opts Options, compressed bool) error {
+	if !compressed {
+		return render(out, tree, fileContents, opts)
+	}
+	zw := gzip.NewWriter(out)
+	if err := render(zw, tree, fileContents, opts); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestWriteIfChanged tests that identical output is not rewritten.
+func TestWriteIfChanged(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	path := filepath.Join(tempDir, "output.txt")
+
+	written, err := writeIfChanged(path, []byte("dump v1"))
+	if err != nil || !written {
+		t.Fatalf("First write: written=%v err=%v, want written", written, err)
+	}
+
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("Failed to set mtime: %v", err)
+	}
+	written, err = writeIfChanged(path, []byte("dump v1"))
+	if err != nil || written {
+		t.Fatalf("Identical write: written=%v err=%v, want skipped", written, err)
+	}
+	if info, _ := os.Stat(path); !info.ModTime().Equal(old) {
+		t.Errorf("Unchanged output was touched: mtime %v, want %v", info.ModTime(), old)
+	}
+
+	written, err = writeIfChanged(path, []byte("dump v2"))
+	if err != nil || !written {
+		t.Fatalf("Changed write: written=%v err=%v, want written", written, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "dump v2" {
+		t.Errorf("Output = %q, want %q", data, "dump v2")
+	}
+}
+
+// TestRenderCompressed tests that the output is gzipped for .gz file names or -compress.
+func TestRenderCompressed(t *testing.T) {
+	for _, tt := range []struct {
+		path  string
+		force bool
+		want  bool
+	}{
+		{"output.txt", false, false},
+		{"output.txt.gz", false, true},
+		{"OUT.GZ", false, true},
+		{"output.txt", true, true},
+		{stdoutName, false, false},
+	} {
+		if got := gzipOutput(tt.path, tt.force); got != tt.want {
+			t.Errorf("gzipOutput(%q, %v) = %v, want %v", tt.path, tt.force, got, tt.want)
+		}
+	}
+
+	tree := newTree("repo")
+	tree.addChild("main.go", "main.go", false)
+	fileContents := map[string]string{"main.go": "package main\n"}
+	opts := Options{ShowTree: true}
+	var plain, zipped bytes.Buffer
+	if err := renderCompressed(&plain, tree, fileContents, opts, false); err != nil {
+		t.Fatalf("renderCompressed() returned error: %v", err)
+	}
+	if err := renderCompressed(&zipped, tree, fileContents, opts, true); err != nil {
+		t.Fatalf("renderCompressed() returned error: %v", err)
+	}
+	zr, err := gzip.NewReader(&zipped)
+	if err != nil {
+		t.Fatalf("Output is not gzipped: %v", err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("Reading gzipped output: %v", err)
+	}
+	if string(got) != plain.String() {
+		t.Errorf("Decompressed output = %q, want %q", got, plain.String())
+	}
+}
+
+// TestSkipOutputViaSymlink tests that the output file is skipped when -o reaches it through a symlinked directory.
+func TestSkipOutputViaSymlink(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	realDir := filepath.Join(tempDir, "real")
+	linkDir := filepath.Join(tempDir, "link")
+
+	writeTestFiles(t, realDir, map[string]string{"main.go": "package main\n", "output.txt": "previous dump\n"})
+	if err := os.Symlink(realDir, linkDir); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	opts := Options{ExcludeList: map[string]bool{}, OutputPath: filepath.Join(linkDir, "output.txt")}
+	_, fileContents, err := buildDirectoryStructure(realDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if _, ok := fileContents["output.txt"]; ok {
+		t.Error("The output file should be skipped even when reached through a symlink")
+	}
+	if _, ok := fileContents["main.go"]; !ok {
+		t.Error("main.go should be included")
+	}
+}
+
+// TestIsOwnOutputShape tests that only the tree-json schema marker and the exact json, yaml and xml shapes
+// identify earlier output.
+func TestIsOwnOutputShape(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"{\n  \"$schema\": \"" + treeJSONSchema + "\",\n  \"name\": \"repo\"\n}\n", true},
+		{"{\n  \"tree\": \"repo/\\n\",\n  \"files\": [\n    {\"path\": \"a.go\", \"size\": 1, \"content\": \"x\"}\n  ]\n}\n", true},
+		{"tree: |\n  repo/\nfiles:\n  - path: a.go\n    size: 1\n    content: x\n", true},
+		{"<documents>\n<document index=\"1\">\n<source>a.go</source>\n<document_contents>x</document_contents>\n</document>\n</documents>\n", true},
+		{"<documents>\n</documents>\n", true},
+		{"{\n  \"$schema\": \"local-gitingest/other/v9\"\n}\n", false},
+		{"{\n  \"tree\": \"oak\",\n  \"files\": [],\n  \"leaves\": 3\n}\n", false},
+		{"{\"tree\": \"oak\", \"files\": [{\"path\": \"a\", \"owner\": \"me\"}]}", false},
+		{"tree: oak\nfiles: 3\n", false},
+		{"<documents>\n<memo>local-gitingest/ingest/v1</memo>\n</documents>\n", false},
+	}
+	for _, tt := range tests {
+		if got := isOwnOutput([]byte(tt.content)); got != tt.want {
+			t.Errorf("isOwnOutput(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
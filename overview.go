@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// summaryMaxLen 是 overview 模式中单行摘要的最大字符数
+const summaryMaxLen = 120
+
+// summaryScanLines 是寻找首个注释或 docstring 时最多查看的行数
+const summaryScanLines = 20
+
+// commentMarkers 是识别摘要时去掉的注释起止符号，较长的在前
+var commentMarkers = []string{"<!--", "-->", "/**", "/*", "*/", "///", "//", "\"\"\"", "'''", "--", "#", "*"}
+
+// isCommentLine 判断去掉缩进后的行是否以注释或 docstring 开头
+func isCommentLine(line string) bool {
+	for _, m := range []string{"//", "#", "/*", "*", "--", "<!--", "\"\"\"", "'''"} {
+		if strings.HasPrefix(line, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripCommentMarkers 去掉行首和行尾的注释符号
+func stripCommentMarkers(line string) string {
+	for changed := true; changed; {
+		changed = false
+		for _, m := range commentMarkers {
+			if strings.HasPrefix(line, m) {
+				line, changed = strings.TrimSpace(line[len(m):]), true
+			}
+			if strings.HasSuffix(line, m) {
+				line, changed = strings.TrimSpace(line[:len(line)-len(m)]), true
+			}
+		}
+	}
+	return line
+}
+
+// fileSummary 返回文件的一行摘要：开头若干行中第一条有内容的注释或 docstring（跳过 shebang 和
+// 版权声明），找不到时使用第一个非空行
+func fileSummary(content string) string {
+	if content == contentOmitted {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	first := ""
+	for i, line := range lines {
+		if i >= summaryScanLines {
+			break
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#!") {
+			continue
+		}
+		if !isCommentLine(line) {
+			if first == "" {
+				first = line
+			}
+			continue
+		}
+		text := stripCommentMarkers(line)
+		lower := strings.ToLower(text)
+		if text == "" || strings.Contains(lower, "copyright") || strings.Contains(lower, "license") {
+			continue
+		}
+		return truncateSummary(text)
+	}
+	return truncateSummary(first)
+}
+
+func truncateSummary(s string) string {
+	if utf8.RuneCountInString(s) <= summaryMaxLen {
+		return s
+	}
+	return string([]rune(s)[:summaryMaxLen-3]) + "..."
+}
+
+// writeOverview 输出仓库概览：提示语、目录树和每个文件的一行摘要，不含文件内容
+func writeOverview(out io.Writer, repoName, dirStructure string, fileContents map[string]string, opts Options) error {
+	fmt.Fprintf(out, "Repository overview: %s\n", repoName)
+	fmt.Fprintf(out, "Below are the directory tree and a one-line summary of each of the %d files. "+
+		"Full file contents are omitted; ask for specific files if you need them.\n\n", len(fileContents))
+	io.WriteString(out, dirStructure)
+	io.WriteString(out, "\nFile summaries:\n")
+	for _, relPath := range sortedPaths(fileContents, opts.SortOrder) {
+		summary := fileSummary(fileContents[relPath])
+		if summary == "" {
+			fmt.Fprintf(out, "- %s%s\n", relPath, langSuffix(relPath, fileContents[relPath], opts))
+			continue
+		}
+		fmt.Fprintf(out, "- %s%s: %s\n", relPath, langSuffix(relPath, fileContents[relPath], opts), summary)
+	}
+	return nil
+}
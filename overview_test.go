@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestFileSummary tests picking the first comment, docstring or non-blank line as a summary.
+func TestFileSummary(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"go doc comment", "// Copyright 2024 Foo\n\n// Package calc adds numbers.\npackage calc\n", "Package calc adds numbers."},
+		{"python docstring", "#!/usr/bin/env python3\n\"\"\"Fetch the latest release.\"\"\"\nimport os\n", "Fetch the latest release."},
+		{"multi-line docstring", "\"\"\"\nParse config files.\n\"\"\"\n", "Parse config files."},
+		{"block comment", "/*\n * Entry point of the web app.\n */\nimport x from 'y';\n", "Entry point of the web app."},
+		{"markdown heading", "# local-gitingest\n\nText.\n", "local-gitingest"},
+		{"first non-blank line", "\n\nkey: value\nother: 1\n", "key: value"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fileSummary(tt.content); got != tt.want {
+				t.Errorf("fileSummary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestOverviewMode tests that the overview has the tree and summaries but no full contents.
+func TestOverviewMode(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":     "// Command demo prints a greeting.\npackage main\n\nfunc main() { println(\"secret body\") }\n",
+		"lib/util.py": "\"\"\"Helpers for string handling.\"\"\"\n\ndef helper():\n    return 'secret body'\n",
+		"config.yaml": "name: demo\n",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, Overview: true}
+	tree, fileContents, err := ingest(tempDir, &opts)
+	if err != nil {
+		t.Fatalf("ingest() returned error: %v", err)
+	}
+	var out bytes.Buffer
+	if err := render(&out, tree, fileContents, opts); err != nil {
+		t.Fatalf("render() returned error: %v", err)
+	}
+	s := out.String()
+
+	for _, want := range []string{
+		"Repository overview:",
+		"util.py",
+		"- main.go: Command demo prints a greeting.",
+		"- lib/util.py: Helpers for string handling.",
+		"- config.yaml: name: demo",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("Overview is missing %q:\n%s", want, s)
+		}
+	}
+	if strings.Contains(s, "secret body") || strings.Contains(s, "File: ") {
+		t.Errorf("Overview should not contain full contents:\n%s", s)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+// parseOwners 将逗号分隔的 uid 或用户名列表解析为 uid 集合
+func parseOwners(s string) (map[uint32]bool, error) {
+	owners := make(map[uint32]bool)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if uid, err := strconv.ParseUint(name, 10, 32); err == nil {
+			owners[uint32(uid)] = true
+			continue
+		}
+		u, err := user.Lookup(name)
+		if err != nil {
+			return nil, fmt.Errorf("unknown owner %q: %w", name, err)
+		}
+		uid, err := strconv.ParseUint(u.Uid, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("owner %q has non-numeric uid %q", name, u.Uid)
+		}
+		owners[uint32(uid)] = true
+	}
+	return owners, nil
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "io/fs"
+
+// fileOwner 在非 Unix 平台上无法获取属主，按属主排除将不起作用
+func fileOwner(info fs.FileInfo) (uint32, bool) {
+	return 0, false
+}
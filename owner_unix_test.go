@@ -0,0 +1,52 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// TestExcludeOwner tests filtering files by their owner uid.
+func TestExcludeOwner(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	uid := os.Getuid()
+	tests := []struct {
+		name          string
+		owners        string
+		expectedCount int
+	}{
+		{name: "Exclude current user", owners: strconv.Itoa(uid), expectedCount: 0},
+		{name: "Exclude other user", owners: strconv.Itoa(uid + 1), expectedCount: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owners, err := parseOwners(tt.owners)
+			if err != nil {
+				t.Fatalf("parseOwners(%q) returned error: %v", tt.owners, err)
+			}
+			opts := Options{ExcludeList: map[string]bool{}, ExcludeOwners: owners}
+			_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+			if err != nil {
+				t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+			}
+			if len(fileContents) != tt.expectedCount {
+				t.Errorf("Got %d files, want %d", len(fileContents), tt.expectedCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pathCases 是 -normalize-paths-case 支持的取值
+var pathCases = map[string]bool{"preserve": true, "lower": true}
+
+// caseCollisions 返回只有大小写不同的文件路径分组（使用 / 分隔），在大小写不敏感的文件系统上它们会互相覆盖
+func caseCollisions(fileContents map[string]string) [][]string {
+	byLower := map[string][]string{}
+	for relPath := range fileContents {
+		p := filepath.ToSlash(relPath)
+		byLower[strings.ToLower(p)] = append(byLower[strings.ToLower(p)], p)
+	}
+	var groups [][]string
+	for _, paths := range byLower {
+		if len(paths) > 1 {
+			sort.Strings(paths)
+			groups = append(groups, paths)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+	return groups
+}
+
+// printCaseCollisions 对只有大小写不同的路径给出警告
+func printCaseCollisions(w io.Writer, groups [][]string) {
+	for _, paths := range groups {
+		fmt.Fprintf(w, "Warning: paths differ only in case: %s\n", strings.Join(paths, ", "))
+	}
+}
+
+// lowerPathCase 将文件路径、目录树中的名称、opts.GitMeta 的路径以及 -list-related-tests 注释中的路径转为小写。
+// 只有大小写不同的路径（及同级条目）保持原样，以免互相覆盖。
+func lowerPathCase(tree *treeNode, fileContents map[string]string, opts *Options) {
+	colliding := map[string]bool{}
+	for _, paths := range caseCollisions(fileContents) {
+		for _, p := range paths {
+			colliding[filepath.FromSlash(p)] = true
+		}
+	}
+	renamed := map[string]string{}
+	for relPath := range fileContents {
+		if lower := strings.ToLower(relPath); lower != relPath && !colliding[relPath] {
+			renamed[relPath] = lower
+		}
+	}
+	for from, to := range renamed {
+		content := fileContents[from]
+		if opts.ListRelatedTests {
+			content = lowerRelatedTestsNote(content, colliding)
+		}
+		fileContents[to] = content
+		delete(fileContents, from)
+		if meta, ok := opts.GitMeta[from]; ok {
+			opts.GitMeta[to] = meta
+			delete(opts.GitMeta, from)
+		}
+	}
+	lowerTreeNames(tree)
+}
+
+// lowerRelatedTestsNote 将 addRelatedTests 插入的注释中的测试文件路径转为小写，与文件头一致
+func lowerRelatedTestsNote(content string, colliding map[string]bool) string {
+	rest, ok := strings.CutPrefix(content, relatedTestsPrefix)
+	if !ok {
+		return content
+	}
+	testFile, names, ok := strings.Cut(rest, ": ")
+	if !ok || colliding[filepath.FromSlash(testFile)] {
+		return content
+	}
+	return relatedTestsPrefix + strings.ToLower(testFile) + ": " + names
+}
+
+// lowerTreeNames 将目录树中各条目的名称转为小写，同级中只有大小写不同的条目保持原样
+func lowerTreeNames(node *treeNode) {
+	count := map[string]int{}
+	for _, child := range node.children {
+		count[strings.ToLower(child.name)]++
+	}
+	for _, child := range node.children {
+		if count[strings.ToLower(child.name)] == 1 {
+			child.name = strings.ToLower(child.name)
+		}
+		lowerTreeNames(child)
+	}
+}
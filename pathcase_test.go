@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestNormalizePathsCase tests lower-casing output paths and detecting paths that differ only in case.
+func TestNormalizePathsCase(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"README.md":      "upper",
+		"readme.md":      "lower",
+		"Docs/Guide.md":  "guide",
+		"pkg/Handler.go": "package pkg",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, PathCase: "lower"}
+	tree, fileContents, err := ingest(tempDir, &opts)
+	if err != nil {
+		t.Fatalf("ingest() returned error: %v", err)
+	}
+
+	groups := caseCollisions(fileContents)
+	if len(groups) != 1 || strings.Join(groups[0], ",") != "README.md,readme.md" {
+		t.Errorf("caseCollisions() = %v, want [[README.md readme.md]]", groups)
+	}
+	var warning strings.Builder
+	printCaseCollisions(&warning, groups)
+	if warning.String() != "Warning: paths differ only in case: README.md, readme.md\n" {
+		t.Errorf("Unexpected warning: %q", warning.String())
+	}
+
+	// Colliding paths keep their original case so neither is lost.
+	expected := map[string]string{
+		"README.md":      "upper",
+		"readme.md":      "lower",
+		"docs/guide.md":  "guide",
+		"pkg/handler.go": "package pkg",
+	}
+	if len(fileContents) != len(expected) {
+		t.Errorf("Got %d files, want %d: %v", len(fileContents), len(expected), fileContents)
+	}
+	for path, content := range expected {
+		if got := fileContents[filepath.FromSlash(path)]; got != content {
+			t.Errorf("fileContents[%q] = %q, want %q", path, got, content)
+		}
+	}
+	rendered := renderTree(tree, opts)
+	for _, line := range []string{"docs/", "guide.md", "handler.go", "README.md", "readme.md"} {
+		if !containsLine(rendered, line) {
+			t.Errorf("Tree is missing %q:\n%s", line, rendered)
+		}
+	}
+}
+
+// TestNormalizePathsCaseLate tests that lower-casing comes after the steps that look paths up on disk or in git.
+func TestNormalizePathsCaseLate(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = tempDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git("init")
+	writeTestFiles(t, tempDir, map[string]string{
+		"Pkg/ARCHITECTURE.md": "# Design",
+		"Pkg/Aaa.go":          "package pkg\n",
+		"Pkg/Foo.go":          "package pkg\n",
+		"Pkg/Foo_test.go":     "package pkg\n\nimport \"testing\"\n\nfunc TestFoo(t *testing.T) {}\n",
+	})
+	git("add", ".")
+	git("commit", "-m", "Add pkg")
+
+	opts := Options{
+		ExcludeList:      map[string]bool{},
+		PathCase:         "lower",
+		ListRelatedTests: true,
+		DocAnchors:       []string{"ARCHITECTURE.md"},
+		JSONGitMeta:      true,
+		Format:           "json",
+	}
+	var out bytes.Buffer
+	if err := writeDirectoryStructure(tempDir, opts, &out); err != nil {
+		t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+	}
+	var ing Ingest
+	if err := json.Unmarshal(out.Bytes(), &ing); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, out.String())
+	}
+
+	var paths []string
+	for _, f := range ing.Files {
+		paths = append(paths, f.Path)
+		if f.Author != "Test" {
+			t.Errorf("%s should carry its git author, got %q", f.Path, f.Author)
+		}
+	}
+	if want := "pkg/aaa.go pkg/architecture.md pkg/foo.go pkg/foo_test.go"; strings.Join(paths, " ") != want {
+		t.Errorf("Paths = %v, want %s", paths, want)
+	}
+	if len(ing.Files) > 2 && !strings.HasPrefix(ing.Files[2].Content, "// tests in pkg/foo_test.go: TestFoo\n") {
+		t.Errorf("pkg/foo.go should list its related tests, got %q", ing.Files[2].Content)
+	}
+
+	// The lower-cased doc anchor still comes first in its directory.
+	opts.Format, opts.ShowBanners = "txt", true
+	out.Reset()
+	if err := writeDirectoryStructure(tempDir, opts, &out); err != nil {
+		t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+	}
+	var order []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if name, ok := strings.CutPrefix(line, "File: "); ok {
+			order = append(order, filepath.ToSlash(name))
+		}
+	}
+	if want := "pkg/architecture.md pkg/aaa.go pkg/foo.go pkg/foo_test.go"; strings.Join(order, " ") != want {
+		t.Errorf("File order = %v, want %s", order, want)
+	}
+
+	// Repeated runs leave the options alone.
+	for range 2 {
+		if _, _, err := ingest(tempDir, &opts); err != nil {
+			t.Fatalf("ingest() returned error: %v", err)
+		}
+	}
+	if len(opts.DocAnchors) != 1 {
+		t.Errorf("DocAnchors should not grow, got %v", opts.DocAnchors)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"sync"
+)
+
+// readJob 是遍历中收集的一个待读取文件
+type readJob struct {
+	path    string
+	relPath string
+	ext     string
+	parent  *treeNode
+	node    *treeNode
+}
+
+// readResult 是读取并处理一个文件的结果
+type readResult struct {
+	text   string
+	reason string // 非空时文件内容不收录，取值为 reasonOwnOutput、reasonBinary、reasonData 或 reasonDenied
+	detail string // reasonDenied 时为命中的标记
+	err    error
+}
+
+// poolSize 返回 -jobs 对应的 goroutine 数，0 或负数表示 runtime.NumCPU()
+func poolSize(jobs int) int {
+	if jobs <= 0 {
+		return runtime.NumCPU()
+	}
+	return jobs
+}
+
+// runPool 用至多 workers 个 goroutine 对 0..n-1 执行 fn，workers 不大于 1 时在当前 goroutine 中依次执行。
+// fn 只应写入与 i 对应的结果，调用方据此按确定的顺序汇总。
+func runPool(workers, n int, fn func(i int)) {
+	workers = min(workers, n)
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for range workers {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// readFiles 用至多 opts.Jobs 个 goroutine（0 表示 runtime.NumCPU()）并发读取并处理文件，
+// 结果与 jobs 一一对应
+func readFiles(jobs []readJob, opts Options) []readResult {
+	results := make([]readResult, len(jobs))
+	runPool(poolSize(opts.Jobs), len(jobs), func(i int) {
+		results[i] = readFile(jobs[i], opts)
+	})
+	return results
+}
+
+// readFile 读取文件内容并按 opts 执行内容变换
+func readFile(job readJob, opts Options) readResult {
+	content, err := os.ReadFile(job.path) //读取文件内容
+	if err != nil {
+		return readResult{err: err}
+	}
+	if isOwnOutput(content) {
+		return readResult{reason: reasonOwnOutput}
+	}
+	if marker := deniedMarker(content, opts.DenyContent); marker != "" {
+		return readResult{reason: reasonDenied, detail: marker}
+	}
+	if isBinary(content) {
+		switch {
+		case opts.BinaryAsHex:
+			return readResult{text: hexDump(content, opts.BinaryHexMaxSize)}
+		case !opts.IncludeBinary:
+			return readResult{reason: reasonBinary}
+		}
+	}
+	if opts.SkipDataFiles && isDataFile(string(content), opts.DataFileRatio) {
+		return readResult{reason: reasonData}
+	}
+	return readResult{text: transformContent(job.relPath, job.ext, string(content), opts)}
+}
+
+// transformContent 按 opts.TransformOrder（为空时按 defaultTransformOrder）对文件内容执行各项变换，
+// 最后按 -content-max-bytes 和 -max-tokens-per-file 截断
+func transformContent(relPath, ext, text string, opts Options) string {
+	order := opts.TransformOrder
+	if order == nil {
+		order = defaultTransformOrder
+	}
+	for _, name := range order {
+		text = transformStages[name](relPath, ext, text, opts)
+	}
+	if opts.ContentMaxBytes > 0 {
+		text = truncateToBytes(text, opts.ContentMaxBytes)
+	}
+	if opts.MaxTokensPerFile > 0 {
+		text = truncateToTokens(text, opts.MaxTokensPerFile)
+	}
+	return text
+}
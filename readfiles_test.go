@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeSyntheticTree creates n small Go files spread over nested directories.
+func writeSyntheticTree(tb testing.TB, dir string, n int) {
+	tb.Helper()
+	body := strings.Repeat("// filler line for a synthetic file\n", 40)
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("pkg%02d/sub%02d/file%04d.go", i%20, i%7, i))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("package p\n"+body), 0644); err != nil {
+			tb.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+}
+
+// TestConcurrentReadDeterministic tests that the result does not depend on the number of workers.
+func TestConcurrentReadDeterministic(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeSyntheticTree(t, tempDir, 200)
+	writeTestFiles(t, tempDir, map[string]string{
+		"blob.bin":  "\x00\x01\x02",
+		"tree.json": `{"$schema": "local-gitingest/tree-json/v1", "name": "x"}`,
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, Jobs: 1, Excluded: &exclusionLog{}}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if len(fileContents) != 200 || !containsLine(tree, "blob.bin (binary, skipped)") || containsLine(tree, "tree.json") {
+		t.Fatalf("Unexpected result: %d files\n%s", len(fileContents), tree)
+	}
+
+	for _, jobs := range []int{0, 8} {
+		opts := Options{ExcludeList: map[string]bool{}, Jobs: jobs, Excluded: &exclusionLog{}}
+		gotTree, gotContents, err := buildDirectoryStructure(tempDir, opts)
+		if err != nil {
+			t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+		}
+		if gotTree != tree || !reflect.DeepEqual(gotContents, fileContents) {
+			t.Errorf("Result with %d jobs differs from the sequential one", jobs)
+		}
+		if len(opts.Excluded.entries) != 2 {
+			t.Errorf("Exclusions with %d jobs = %v, want 2", jobs, opts.Excluded.entries)
+		}
+	}
+}
+
+// BenchmarkReadFiles compares sequential and concurrent reading of a synthetic tree of a few thousand files.
+func BenchmarkReadFiles(b *testing.B) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		b.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	writeSyntheticTree(b, tempDir, 3000)
+
+	for _, jobs := range []int{1, 0} {
+		name := fmt.Sprintf("jobs=%d", jobs)
+		if jobs == 0 {
+			name = "jobs=NumCPU"
+		}
+		b.Run(name, func(b *testing.B) {
+			opts := Options{ExcludeList: map[string]bool{}, Jobs: jobs}
+			for i := 0; i < b.N; i++ {
+				if _, _, err := walkRepository(tempDir, opts); err != nil {
+					b.Fatalf("walkRepository() returned error: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestListRelatedTests tests annotating Go files with the test names from their sibling _test.go.
+func TestListRelatedTests(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"calc.go": "package calc\n\nfunc Add(a, b int) int { return a + b }\n",
+		"calc_test.go": "package calc\n\nimport \"testing\"\n\n" +
+			"func TestAdd(t *testing.T) { if Add(1, 2) != 3 { t.Fail() } }\n" +
+			"func BenchmarkAdd(b *testing.B) {}\n" +
+			"func helper() {}\n",
+		"util.go": "package calc\n",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, ListRelatedTests: true}
+	_, fileContents, err := ingest(tempDir, &opts)
+	if err != nil {
+		t.Fatalf("ingest() returned error: %v", err)
+	}
+
+	want := "// tests in calc_test.go: TestAdd, BenchmarkAdd\npackage calc\n"
+	if got := fileContents["calc.go"]; !strings.HasPrefix(got, want) {
+		t.Errorf("calc.go = %q, want prefix %q", got, want)
+	}
+	if got := fileContents["util.go"]; got != "package calc\n" {
+		t.Errorf("util.go has no sibling test and should be unchanged, got %q", got)
+	}
+	if got := fileContents["calc_test.go"]; strings.HasPrefix(got, "// tests in") {
+		t.Errorf("Test files themselves should not be annotated, got %q", got)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+// renderPreset 是 -render 模式对应的一组输出开关
+type renderPreset struct {
+	tree, banners, toc, meta, sizes bool
+}
+
+// renderPresets 定义 -render 支持的模式：
+// minimal 只输出文件内容；standard 为默认的目录树 + 带横幅的文件；rich 额外输出元信息、目录和文件大小。
+var renderPresets = map[string]renderPreset{
+	"minimal":  {},
+	"standard": {tree: true, banners: true},
+	"rich":     {tree: true, banners: true, toc: true, meta: true, sizes: true},
+}
+
+// applyRenderMode 按 mode 设置输出开关，explicit 中列出的开关由用户显式指定，保留原值
+func applyRenderMode(opts *Options, mode string, explicit map[string]bool) error {
+	preset, ok := renderPresets[mode]
+	if !ok {
+		return fmt.Errorf("unknown render mode %q (want minimal, standard or rich)", mode)
+	}
+	if !explicit["tree"] {
+		opts.ShowTree = preset.tree
+	}
+	if !explicit["banners"] {
+		opts.ShowBanners = preset.banners
+	}
+	if !explicit["toc"] {
+		opts.ShowTOC = preset.toc
+	}
+	if !explicit["meta"] {
+		opts.ShowMeta = preset.meta
+	}
+	if !explicit["file-sizes"] {
+		opts.ShowSizes = preset.sizes
+	}
+	return nil
+}
+
+// explicitFlags 返回命令行中显式设置过的 flag 名称
+func explicitFlags() map[string]bool {
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	return explicit
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestRenderModes tests the composition of each -render preset.
+func TestRenderModes(t *testing.T) {
+	tree := "repo/\nmain.go\n"
+	fileContents := map[string]string{"main.go": "package main\n"}
+
+	tests := []struct {
+		mode     string
+		explicit map[string]bool
+		preset   Options // Only the Show* fields are set.
+		want     []string
+		notWant  []string
+	}{
+		{
+			mode:    "minimal",
+			want:    []string{"File: main.go\npackage main\n"},
+			notWant: []string{"repo/", "=====", "Table of contents", "Files: ", "bytes)"},
+		},
+		{
+			mode:    "standard",
+			want:    []string{"repo/\nmain.go\n", "=====\nFile: main.go\n====="},
+			notWant: []string{"Table of contents", "Files: ", "bytes)"},
+		},
+		{
+			mode: "rich",
+			want: []string{"Files: 1\nTotal size: 13 bytes\n", "repo/\nmain.go\n", "Table of contents:\n- main.go (13 bytes)\n", "File: main.go (13 bytes)\n"},
+		},
+		{
+			mode:     "minimal",
+			explicit: map[string]bool{"tree": true},
+			preset:   Options{ShowTree: true},
+			want:     []string{"repo/\nmain.go\n"},
+			notWant:  []string{"====="},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			opts := tt.preset
+			if err := applyRenderMode(&opts, tt.mode, tt.explicit); err != nil {
+				t.Fatalf("applyRenderMode(%q) returned error: %v", tt.mode, err)
+			}
+			var buf bytes.Buffer
+			if err := writeOutput(&buf, tree, fileContents, opts); err != nil {
+				t.Fatalf("writeOutput() returned error: %v", err)
+			}
+			got := buf.String()
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("Output is missing %q:\n%s", s, got)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(got, s) {
+					t.Errorf("Output should not contain %q:\n%s", s, got)
+				}
+			}
+		})
+	}
+
+	if err := applyRenderMode(&Options{}, "fancy", nil); err == nil {
+		t.Error("Expected an error for an unknown render mode")
+	}
+}
+
+// TestMergeSmallFiles tests that small files share one block while large ones stay separate.
+func TestMergeSmallFiles(t *testing.T) {
+	large := strings.Repeat("x", 100)
+	fileContents := map[string]string{
+		"b.txt":     "bee\n",
+		"a.txt":     "ay",
+		"large.txt": large,
+	}
+	opts := Options{ShowBanners: true, MergeSmallFiles: 50}
+
+	var buf bytes.Buffer
+	if err := writeOutput(&buf, "", fileContents, opts); err != nil {
+		t.Fatalf("writeOutput() returned error: %v", err)
+	}
+	got := buf.String()
+
+	if !strings.Contains(got, "File: large.txt\n=") {
+		t.Errorf("Large file should keep its own block:\n%s", got)
+	}
+	want := "Small files (2)\n================================================\n--- a.txt ---\nay\n--- b.txt ---\nbee\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("Output is missing merged block %q:\n%s", want, got)
+	}
+	if strings.Contains(got, "File: a.txt") || strings.Contains(got, "File: b.txt") {
+		t.Errorf("Small files should not get their own banners:\n%s", got)
+	}
+}
+
+// TestSortBySize tests ordering files by size with a deterministic path tie-break.
+func TestSortBySize(t *testing.T) {
+	fileContents := map[string]string{
+		"b.go":     "12345",
+		"a.go":     "12345",
+		"big.go":   "1234567890",
+		"small.go": "1",
+	}
+	tests := map[string][]string{
+		"path":      {"a.go", "b.go", "big.go", "small.go"},
+		"size-desc": {"big.go", "a.go", "b.go", "small.go"},
+		"size-asc":  {"small.go", "a.go", "b.go", "big.go"},
+	}
+	for order, want := range tests {
+		got := sortedPaths(fileContents, order)
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("sortedPaths(%s) = %v, want %v", order, got, want)
+		}
+	}
+
+	var out bytes.Buffer
+	opts := Options{SortOrder: "size-desc"}
+	if err := writeOutput(&out, "", fileContents, opts); err != nil {
+		t.Fatalf("writeOutput() returned error: %v", err)
+	}
+	s := out.String()
+	if !(strings.Index(s, "File: big.go") < strings.Index(s, "File: a.go") &&
+		strings.Index(s, "File: a.go") < strings.Index(s, "File: b.go") &&
+		strings.Index(s, "File: b.go") < strings.Index(s, "File: small.go")) {
+		t.Errorf("Files are not written largest first:\n%s", s)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// parseIncludeDirs 解析 -dir 的逗号分隔列表，返回清理后的相对路径。
+// 路径必须是仓库内已存在的目录。
+func parseIncludeDirs(rootDir, s string) ([]string, error) {
+	var dirs []string
+	for _, dir := range strings.Split(s, ",") {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			continue
+		}
+		clean := filepath.Clean(filepath.FromSlash(dir))
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%s: must be a path relative to the repository root", dir)
+		}
+		info, err := os.Stat(filepath.Join(rootDir, clean))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dir, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("%s: not a directory", dir)
+		}
+		if clean == "." {
+			return nil, nil // 整个仓库
+		}
+		dirs = append(dirs, clean)
+	}
+	return dirs, nil
+}
+
+// dirScope 判断相对路径与 -dir 限定范围的关系：inside 表示位于某个限定目录之内（含其本身），
+// ancestor 表示是某个限定目录的上级目录，需要进入但不收录其中的文件
+func dirScope(relPath string, dirs []string) (inside, ancestor bool) {
+	sep := string(filepath.Separator)
+	for _, dir := range dirs {
+		if relPath == dir || strings.HasPrefix(relPath, dir+sep) {
+			return true, false
+		}
+		if strings.HasPrefix(dir, relPath+sep) {
+			ancestor = true
+		}
+	}
+	return false, ancestor
+}
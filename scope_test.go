@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestIncludeDirs tests restricting the walk to a list of subdirectories.
+func TestIncludeDirs(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":             "package main",
+		"internal/a/a.go":     "package a",
+		"internal/b.go":       "package internal",
+		"cmd/tool/main.go":    "package main",
+		"cmd/other/main.go":   "package main",
+		"cmd/README.md":       "commands",
+		"internal-extra/x.go": "package x",
+		"docs/guide.md":       "guide",
+	})
+
+	dirs, err := parseIncludeDirs(tempDir, "internal, cmd/tool/")
+	if err != nil {
+		t.Fatalf("parseIncludeDirs() returned error: %v", err)
+	}
+	opts := Options{ExcludeList: map[string]bool{}, IncludeDirs: dirs}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	want := []string{"internal/a/a.go", "internal/b.go", "cmd/tool/main.go"}
+	for _, path := range want {
+		if _, ok := fileContents[filepath.FromSlash(path)]; !ok {
+			t.Errorf("Expected file not found: %s", path)
+		}
+	}
+	if len(fileContents) != len(want) {
+		t.Errorf("Got %d files, want %d: %v", len(fileContents), len(want), fileContents)
+	}
+	for _, line := range []string{"other/", "docs/", "internal-extra/", "README.md"} {
+		if containsLine(tree, line) {
+			t.Errorf("Tree should only show the included subtrees, found %q:\n%s", line, tree)
+		}
+	}
+	if !containsLine(tree, "cmd/") || !containsLine(tree, "tool/") {
+		t.Errorf("Tree should show the path to cmd/tool:\n%s", tree)
+	}
+
+	for _, bad := range []string{"missing", "main.go", "../outside"} {
+		if _, err := parseIncludeDirs(tempDir, bad); err == nil {
+			t.Errorf("parseIncludeDirs(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits 是 parseSize 支持的单位后缀（不区分大小写），按 1024 进制换算
+var sizeUnits = map[string]int64{
+	"":   1,
+	"b":  1,
+	"k":  1 << 10,
+	"kb": 1 << 10,
+	"m":  1 << 20,
+	"mb": 1 << 20,
+	"g":  1 << 30,
+	"gb": 1 << 30,
+}
+
+// parseSize 解析字节数，如 "1024"、"500k"、"1.5MB" 或 "2G"。单位按 1024 进制换算，
+// 不带单位的整数按字节计算；结果不足一字节的部分舍去。
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	number, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	multiplier, ok := sizeUnits[unit]
+	if number == "" || !ok {
+		return 0, fmt.Errorf("invalid size %q (want bytes or a number with k, KB, M, MB, G or GB)", s)
+	}
+	if n, err := strconv.ParseInt(number, 10, 64); err == nil {
+		if n > math.MaxInt64/multiplier {
+			return 0, fmt.Errorf("size %q is too large", s)
+		}
+		return n * multiplier, nil
+	}
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q (want bytes or a number with k, KB, M, MB, G or GB)", s)
+	}
+	if f*float64(multiplier) >= math.MaxInt64 {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return int64(f * float64(multiplier)), nil
+}
+
+// byteSize 是接受 parseSize 格式的字节数 flag
+type byteSize int64
+
+func (b *byteSize) String() string {
+	return strconv.FormatInt(int64(*b), 10)
+}
+
+func (b *byteSize) Set(s string) error {
+	n, err := parseSize(s)
+	if err != nil {
+		return err
+	}
+	*b = byteSize(n)
+	return nil
+}
+
+// applySizeBudget 按 paths 的顺序累计文件内容的字节数，一旦超过 maxBytes 就不再收录后续文件。
+// 被跳过的文件从 fileContents 中删除（目录树中仍保留），返回它们的路径。
+func applySizeBudget(fileContents map[string]string, paths []string, maxBytes int64) []string {
+	var skipped []string
+	var used int64
+	for _, relPath := range paths {
+		if skipped == nil {
+			used += int64(len(fileContents[relPath]))
+			if used <= maxBytes {
+				continue
+			}
+		}
+		skipped = append(skipped, filepath.ToSlash(relPath))
+		delete(fileContents, relPath)
+	}
+	return skipped
+}
+
+// applyBudgets 按输出顺序依次应用 -max-tokens 和 -max-output 预算，超出的文件从 fileContents 中删除
+// （目录树中仍保留）并在 w 上警告，返回被删除的路径（以 / 分隔）
+func applyBudgets(w io.Writer, fileContents map[string]string, opts Options) map[string]bool {
+	skipped := map[string]bool{}
+	if opts.MaxTokens > 0 {
+		if paths := applyTokenBudget(fileContents, outputOrder(fileContents, opts), opts.MaxTokens); len(paths) > 0 {
+			fmt.Fprintf(w, "Warning: -max-tokens %d exceeded, skipped %d files:\n", opts.MaxTokens, len(paths))
+			for _, path := range paths {
+				fmt.Fprintf(w, "  %s\n", path)
+				skipped[path] = true
+			}
+		}
+	}
+	if opts.MaxOutput > 0 {
+		if paths := applySizeBudget(fileContents, outputOrder(fileContents, opts), opts.MaxOutput); len(paths) > 0 {
+			fmt.Fprintf(w, "Warning: -max-output %d bytes exceeded, omitted %d files:\n", opts.MaxOutput, len(paths))
+			for _, path := range paths {
+				fmt.Fprintf(w, "  %s\n", path)
+				skipped[path] = true
+			}
+		}
+	}
+	return skipped
+}
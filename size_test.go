@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSizeBudget tests that files past the -max-output budget are dropped in output order.
+func TestSizeBudget(t *testing.T) {
+	fileContents := map[string]string{
+		"a.go": strings.Repeat("a", 400),
+		"b.go": strings.Repeat("b", 500),
+		"c.go": strings.Repeat("c", 200), // would exceed the budget
+		"d.go": "d",                      // fits, but comes after the budget is exceeded
+	}
+	var budget byteSize
+	if err := budget.Set("1k"); err != nil {
+		t.Fatalf("Set() returned error: %v", err)
+	}
+	skipped := applySizeBudget(fileContents, []string{"a.go", "b.go", "c.go", "d.go"}, int64(budget))
+
+	if strings.Join(skipped, ",") != "c.go,d.go" {
+		t.Errorf("skipped = %v, want [c.go d.go]", skipped)
+	}
+	if len(fileContents) != 2 || fileContents["a.go"] == "" || fileContents["b.go"] == "" {
+		t.Errorf("Unexpected remaining files: %v", fileContents)
+	}
+}
+
+// TestParseSize tests plain byte counts and the k/M/G suffixes.
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"0":     0,
+		"1024":  1024,
+		"500k":  500 << 10,
+		"10MB":  10 << 20,
+		"2G":    2 << 30,
+		"64 kb": 64 << 10,
+	}
+	for input, want := range tests {
+		if got, err := parseSize(input); err != nil || got != want {
+			t.Errorf("parseSize(%q) = %d, %v, want %d", input, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "MB", "10TB", "-5", "ten"} {
+		if _, err := parseSize(bad); err == nil {
+			t.Errorf("parseSize(%q) should fail", bad)
+		}
+	}
+}
+
+// TestParseSizeEdgeCases tests fractional sizes, casing, whitespace and overflow.
+func TestParseSizeEdgeCases(t *testing.T) {
+	tests := map[string]int64{
+		"1.5MB":  3 << 19,
+		"0.5k":   512,
+		"1.5":    1,
+		"1gb":    1 << 30,
+		"100B":   100,
+		" 2 Mb ": 2 << 20,
+		"1.":     1,
+	}
+	for input, want := range tests {
+		if got, err := parseSize(input); err != nil || got != want {
+			t.Errorf("parseSize(%q) = %d, %v, want %d", input, got, err, want)
+		}
+	}
+	for _, bad := range []string{".", "1.2.3MB", "1e3", "9223372036854775807k", "99999999999GB", "5 M B"} {
+		if _, err := parseSize(bad); err == nil {
+			t.Errorf("parseSize(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+//go:build sqlite
+
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sort"
+
+	_ "modernc.org/sqlite"
+)
+
+func init() {
+	fileFormats["sqlite"] = writeSQLite
+}
+
+// writeSQLite 将收录的文件写入 SQLite 数据库的 files 表，已存在的数据库会被覆盖
+func writeSQLite(path string, fileContents map[string]string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`CREATE TABLE files (
+		path    TEXT PRIMARY KEY,
+		size    INTEGER NOT NULL,
+		hash    TEXT NOT NULL,
+		content TEXT NOT NULL
+	)`); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare("INSERT INTO files (path, size, hash, content) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	paths := make([]string, 0, len(fileContents))
+	for relPath := range fileContents {
+		paths = append(paths, relPath)
+	}
+	sort.Strings(paths)
+	for _, relPath := range paths {
+		content := fileContents[relPath]
+		if _, err := stmt.Exec(filepath.ToSlash(relPath), len(content), contentHash(content), content); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
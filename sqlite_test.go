@@ -0,0 +1,52 @@
+//go:build sqlite
+
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestWriteSQLite tests querying the SQLite snapshot for a known file.
+func TestWriteSQLite(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	fileContents := map[string]string{
+		"main.go":                       "package main\n",
+		filepath.Join("pkg", "util.go"): "package pkg\n",
+	}
+	dbPath := filepath.Join(tempDir, "snapshot.db")
+	if err := writeSQLite(dbPath, fileContents); err != nil {
+		t.Fatalf("writeSQLite() returned error: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	var size int
+	var hash, content string
+	row := db.QueryRow("SELECT size, hash, content FROM files WHERE path = ?", "pkg/util.go")
+	if err := row.Scan(&size, &hash, &content); err != nil {
+		t.Fatalf("Query returned error: %v", err)
+	}
+	if content != "package pkg\n" || size != len(content) || hash != contentHash(content) {
+		t.Errorf("Got size=%d hash=%s content=%q", size, hash, content)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM files").Scan(&count); err != nil {
+		t.Fatalf("Query returned error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Got %d rows, want 2", count)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// applyOptionsJSON 从 r 读取一个 JSON 对象，键为 flag 名称（如 "max-size"），值为字符串、数字、
+// 布尔值，或可重复 flag 的字符串数组。命令行中显式给出的 flag 优先，JSON 中的同名项被忽略。
+func applyOptionsJSON(fs *flag.FlagSet, r io.Reader) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var values map[string]any
+	if err := dec.Decode(&values); err != nil {
+		return fmt.Errorf("decoding options JSON: %w", err)
+	}
+	return applyOptionValues(fs, values)
+}
+
+// applyOptionValues 将 flag 名称 -> 值 应用到 fs，已经设置过的 flag 保持不变
+func applyOptionValues(fs *flag.FlagSet, values map[string]any) error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("unknown option %q", name)
+		}
+		if explicit[name] {
+			continue
+		}
+		var args []string
+		switch v := values[name].(type) {
+		case string:
+			args = []string{v}
+		case bool:
+			args = []string{strconv.FormatBool(v)}
+		case json.Number:
+			args = []string{v.String()}
+		case int:
+			args = []string{strconv.Itoa(v)}
+		case float64:
+			args = []string{strconv.FormatFloat(v, 'f', -1, 64)}
+		case []any:
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return fmt.Errorf("option %q: array items must be strings", name)
+				}
+				args = append(args, s)
+			}
+		default:
+			return fmt.Errorf("option %q: unsupported value %v", name, v)
+		}
+		for _, arg := range args {
+			if err := fs.Set(name, arg); err != nil {
+				return fmt.Errorf("option %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
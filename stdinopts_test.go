@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+// TestOptionsStdin tests applying a JSON options blob, with command-line flags taking precedence.
+func TestOptionsStdin(t *testing.T) {
+	var (
+		exclude   string
+		sizeLimit bool
+		maxSize   int64
+		patterns  stringList
+	)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.StringVar(&exclude, "exclude", "", "")
+	fs.BoolVar(&sizeLimit, "size-limit", false, "")
+	fs.Int64Var(&maxSize, "max-size", 50*1024, "")
+	fs.Var(&patterns, "gitignore-pattern", "")
+	if err := fs.Parse([]string{"-max-size", "10"}); err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	blob := `{"exclude": ".log,.tmp", "size-limit": true, "max-size": 999, "gitignore-pattern": ["build/", "*.pb.go"]}`
+	if err := applyOptionsJSON(fs, strings.NewReader(blob)); err != nil {
+		t.Fatalf("applyOptionsJSON() returned error: %v", err)
+	}
+	if exclude != ".log,.tmp" || !sizeLimit {
+		t.Errorf("exclude = %q, size-limit = %v; want values from JSON", exclude, sizeLimit)
+	}
+	if maxSize != 10 {
+		t.Errorf("max-size = %d, want 10 from the command line", maxSize)
+	}
+	if strings.Join(patterns, " ") != "build/ *.pb.go" {
+		t.Errorf("gitignore-pattern = %v, want both JSON entries", patterns)
+	}
+
+	for _, bad := range []string{`{"no-such-flag": 1}`, `{"size-limit": "maybe"}`, `{"exclude": {}}`, `not json`} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.StringVar(&exclude, "exclude", "", "")
+		fs.BoolVar(&sizeLimit, "size-limit", false, "")
+		if err := applyOptionsJSON(fs, strings.NewReader(bad)); err == nil {
+			t.Errorf("applyOptionsJSON(%s) should fail", bad)
+		}
+	}
+}
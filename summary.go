@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// summaryLargest 是摘要中列出的最大文件数
+const summaryLargest = 5
+
+// fileSize 是一个收录文件的大小
+type fileSize struct {
+	Path  string
+	Bytes int
+}
+
+// runSummary 汇总一次运行收录和排除的情况，由遍历中填写的 exclusionLog 和最终的文件内容构建
+type runSummary struct {
+	Included   int
+	TotalBytes int
+	Excluded   map[string]int // 排除原因 -> 路径数
+	Largest    []fileSize     // 最大的几个文件，按大小降序
+}
+
+// newRunSummary 构建运行摘要，log 为 nil 时不统计排除情况
+func newRunSummary(fileContents map[string]string, log *exclusionLog) runSummary {
+	s := runSummary{Included: len(fileContents), Excluded: make(map[string]int)}
+	sizes := make([]fileSize, 0, len(fileContents))
+	for relPath, content := range fileContents {
+		s.TotalBytes += len(content)
+		sizes = append(sizes, fileSize{filepath.ToSlash(relPath), len(content)})
+	}
+	sort.Slice(sizes, func(i, j int) bool {
+		if sizes[i].Bytes != sizes[j].Bytes {
+			return sizes[i].Bytes > sizes[j].Bytes
+		}
+		return sizes[i].Path < sizes[j].Path
+	})
+	s.Largest = sizes[:min(len(sizes), summaryLargest)]
+	if log != nil {
+		for _, e := range log.entries {
+			s.Excluded[e.Reason]++
+		}
+	}
+	return s
+}
+
+// write 输出摘要，排除原因按数量降序排列
+func (s runSummary) write(w io.Writer) {
+	total := 0
+	reasons := make([]string, 0, len(s.Excluded))
+	for reason, n := range s.Excluded {
+		total += n
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if s.Excluded[reasons[i]] != s.Excluded[reasons[j]] {
+			return s.Excluded[reasons[i]] > s.Excluded[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	parts := make([]string, len(reasons))
+	for i, reason := range reasons {
+		parts[i] = fmt.Sprintf("%s: %d", reason, s.Excluded[reason])
+	}
+
+	fmt.Fprintln(w, "Summary:")
+	fmt.Fprintf(w, "  Included: %d files, %d bytes\n", s.Included, s.TotalBytes)
+	if total > 0 {
+		fmt.Fprintf(w, "  Excluded: %d (%s)\n", total, strings.Join(parts, ", "))
+	} else {
+		fmt.Fprintln(w, "  Excluded: 0")
+	}
+	if len(s.Largest) > 0 {
+		fmt.Fprintln(w, "  Largest files:")
+		for _, f := range s.Largest {
+			fmt.Fprintf(w, "    %8d  %s\n", f.Bytes, f.Path)
+		}
+	}
+}
+
+// statsHeaderLines 返回 -stats-header 的各行：文件数、总大小、估算 token 数和语言分布，均为 "key: value" 形式
+func statsHeaderLines(fileContents map[string]string) []string {
+	total, tokens := 0, 0
+	for _, content := range fileContents {
+		total += len(content)
+		tokens += estimateTokens(content)
+	}
+	stats := languageBreakdown(fileContents)
+	langs := make([]string, len(stats))
+	for i, stat := range stats {
+		langs[i] = fmt.Sprintf("%s %.1f%%", stat.Language, stat.Percent)
+	}
+	return []string{
+		"local-gitingest stats",
+		fmt.Sprintf("files: %d", len(fileContents)),
+		fmt.Sprintf("total-size: %d bytes", total),
+		fmt.Sprintf("estimated-tokens: %d", tokens),
+		fmt.Sprintf("languages: %s", strings.Join(langs, ", ")),
+	}
+}
+
+// writeStatsHeader 以注释块的形式在输出开头写出统计信息：md 格式使用 HTML 注释，其余格式每行以 "# " 开头
+func writeStatsHeader(out io.Writer, fileContents map[string]string, format string) {
+	lines := statsHeaderLines(fileContents)
+	if format == "md" {
+		fmt.Fprintf(out, "<!--\n%s\n-->\n\n", strings.Join(lines, "\n"))
+		return
+	}
+	for _, line := range lines {
+		fmt.Fprintf(out, "# %s\n", line)
+	}
+	io.WriteString(out, "\n")
+}
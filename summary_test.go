@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestRunSummary tests the summary of included and excluded files.
+func TestRunSummary(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		".gitignore":    "build/\n",
+		"main.go":       "package main\n",
+		"big.go":        strings.Repeat("x", 100),
+		"logo.png":      "\x89PNG\x00",
+		"notes.log":     "log",
+		"huge.txt":      strings.Repeat("y", 200),
+		"build/out.txt": "artifact",
+	})
+
+	opts := Options{
+		ExcludeList:      map[string]bool{".log": true},
+		UseGitignore:     true,
+		IncludeSizeLimit: true,
+		SizeLimit:        150,
+		Excluded:         &exclusionLog{},
+	}
+	_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	var buf strings.Builder
+	newRunSummary(fileContents, opts.Excluded).write(&buf)
+	expected := "Summary:\n" +
+		"  Included: 3 files, 120 bytes\n" +
+		"  Excluded: 4 (binary: 1, extension: 1, gitignore: 1, size: 1)\n" +
+		"  Largest files:\n" +
+		"         100  big.go\n" +
+		"          13  main.go\n" +
+		"           7  .gitignore\n"
+	if buf.String() != expected {
+		t.Errorf("Summary =\n%s\nwant\n%s", buf.String(), expected)
+	}
+}
+
+// TestStatsHeader tests the comment block written at the top of the output.
+func TestStatsHeader(t *testing.T) {
+	fileContents := map[string]string{
+		"main.go":   strings.Repeat("g", 30),
+		"README.md": strings.Repeat("m", 10),
+	}
+	var out strings.Builder
+	if err := writeOutput(&out, "", fileContents, Options{StatsHeader: true}); err != nil {
+		t.Fatalf("writeOutput() returned error: %v", err)
+	}
+	expected := "# local-gitingest stats\n" +
+		"# files: 2\n" +
+		"# total-size: 40 bytes\n" +
+		"# estimated-tokens: 11\n" +
+		"# languages: Go 75.0%, Markdown 25.0%\n" +
+		"\n"
+	if !strings.HasPrefix(out.String(), expected) {
+		t.Errorf("Output does not start with the stats header:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := writeMarkdown(&out, "", fileContents, Options{StatsHeader: true}); err != nil {
+		t.Fatalf("writeMarkdown() returned error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "<!--\nlocal-gitingest stats\nfiles: 2\n") || !strings.Contains(out.String(), "Markdown 25.0%\n-->\n\n") {
+		t.Errorf("Markdown output does not start with the stats comment:\n%s", out.String())
+	}
+}
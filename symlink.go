@@ -0,0 +1,28 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// followLink 判断 -follow-symlinks 时是否进入指向目录的符号链接 path。目标（解析后的真实路径）在仓库根目录
+// realRoot 之内时不进入，因为主遍历已经覆盖了它，否则其中的文件会以两个路径重复收录；目标已进入过、目标是链接
+// 自身的上级目录（会形成循环），或者未开启 allowOutside 时也不进入。决定进入的目标记入 visited。
+func followLink(path, realRoot string, allowOutside bool, visited map[string]bool) (bool, error) {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false, err
+	}
+	if target == realRoot || strings.HasPrefix(target, realRoot+string(filepath.Separator)) || !allowOutside {
+		return false, nil
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return false, err
+	}
+	if visited[target] || parent == target || strings.HasPrefix(parent, target+string(filepath.Separator)) {
+		return false, nil
+	}
+	visited[target] = true
+	return true, nil
+}
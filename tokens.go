@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// charsPerToken 是粗略估算 token 数时使用的字符/token 比例
+const charsPerToken = 4
+
+// estimateTokens 以 chars/4 的启发式方法估算文本的 token 数
+func estimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + charsPerToken - 1) / charsPerToken
+}
+
+// truncateToTokens 从头部截取文本，使其估算 token 数不超过 maxTokens。
+// 截断按整行进行，并在末尾追加一行说明。
+func truncateToTokens(s string, maxTokens int) string {
+	total := estimateTokens(s)
+	if total <= maxTokens {
+		return s
+	}
+
+	var b strings.Builder
+	budget := maxTokens * charsPerToken
+	used := 0
+	for _, line := range strings.SplitAfter(s, "\n") {
+		n := utf8.RuneCountInString(line)
+		if used+n > budget {
+			break
+		}
+		b.WriteString(line)
+		used += n
+	}
+	if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(fmt.Sprintf("... [truncated: ~%d tokens, limit %d]\n", total, maxTokens))
+	return b.String()
+}
+
+// truncateToBytes 从头部截取文本，使保留的内容连同末尾追加的一行说明不超过 maxBytes 字节。
+// 截断按整行进行；放不下任何一行（包括 maxBytes 小于说明本身）时只保留说明。
+func truncateToBytes(s string, maxBytes int64) string {
+	if int64(len(s)) <= maxBytes {
+		return s
+	}
+
+	note := func(kept int) string {
+		return fmt.Sprintf("... [truncated: showing first %d of %d bytes]\n", kept, len(s))
+	}
+	// 保留的字节数不超过 len(s)，按它预留的说明长度足够
+	budget := maxBytes - int64(len(note(len(s))))
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if int64(b.Len()+len(line)) > budget {
+			break
+		}
+		b.WriteString(line)
+	}
+	b.WriteString(note(b.Len()))
+	return b.String()
+}
+
+// tokenCount 是单个文件的估算 token 数
+type tokenCount struct {
+	Path   string
+	Tokens int
+}
+
+// tokenCounts 返回每个文件的估算 token 数，按 token 数降序排列，相同时按路径排序
+func tokenCounts(fileContents map[string]string) []tokenCount {
+	counts := make([]tokenCount, 0, len(fileContents))
+	for relPath, content := range fileContents {
+		counts = append(counts, tokenCount{filepath.ToSlash(relPath), estimateTokens(content)})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Tokens != counts[j].Tokens {
+			return counts[i].Tokens > counts[j].Tokens
+		}
+		return counts[i].Path < counts[j].Path
+	})
+	return counts
+}
+
+// printTokenCounts 输出文件内容的估算 token 总数以及每个文件的明细
+func printTokenCounts(w io.Writer, fileContents map[string]string) {
+	counts := tokenCounts(fileContents)
+	total := 0
+	for _, c := range counts {
+		total += c.Tokens
+	}
+	fmt.Fprintf(w, "Estimated tokens: %d (%d files)\n", total, len(counts))
+	for _, c := range counts {
+		fmt.Fprintf(w, "  %8d  %s\n", c.Tokens, c.Path)
+	}
+}
+
+// printFileCountWarning 在收录的文件数超过 threshold 时输出醒目的警告，建议收紧过滤条件，只作提示
+func printFileCountWarning(w io.Writer, count, threshold int) {
+	if count <= threshold {
+		return
+	}
+	fmt.Fprintf(w, "WARNING: %d files included (more than %d). The output may be too large to be useful;\n", count, threshold)
+	fmt.Fprintln(w, "WARNING: consider tighter filters such as -dir, -include, -exclude, -exclude-glob or -max-tokens.")
+}
+
+// applyTokenBudget 按 paths 的顺序累计文件的估算 token 数，一旦超过 maxTokens 就不再收录后续文件。
+// 被跳过的文件从 fileContents 中删除，返回它们的路径。
+func applyTokenBudget(fileContents map[string]string, paths []string, maxTokens int) []string {
+	var skipped []string
+	used := 0
+	for _, relPath := range paths {
+		if skipped == nil {
+			used += estimateTokens(fileContents[relPath])
+			if used <= maxTokens {
+				continue
+			}
+		}
+		skipped = append(skipped, filepath.ToSlash(relPath))
+		delete(fileContents, relPath)
+	}
+	return skipped
+}
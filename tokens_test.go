@@ -0,0 +1,158 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEstimateTokens tests the chars/4 heuristic.
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"", 0},
+		{"abc", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"你好世界", 1}, // Counted in runes, not bytes.
+	}
+	for _, tt := range tests {
+		if got := estimateTokens(tt.input); got != tt.expected {
+			t.Errorf("estimateTokens(%q) = %d, want %d", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// TestMaxTokensPerFile tests that only oversized files are truncated.
+func TestMaxTokensPerFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	small := "short file\n"
+	large := strings.Repeat("0123456789abcdef\n", 20) // ~85 tokens
+	if err := os.WriteFile(filepath.Join(tempDir, "small.txt"), []byte(small), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "large.txt"), []byte(large), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	opts := Options{ExcludeList: map[string]bool{}, MaxTokensPerFile: 20}
+	_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	if fileContents["small.txt"] != small {
+		t.Errorf("small.txt should be untouched, got %q", fileContents["small.txt"])
+	}
+
+	got := fileContents["large.txt"]
+	if !strings.Contains(got, "[truncated:") {
+		t.Errorf("large.txt should carry a truncation note, got %q", got)
+	}
+	if !strings.HasPrefix(large, strings.SplitAfter(got, "\n")[0]) {
+		t.Errorf("large.txt should be truncated head-wise, got %q", got)
+	}
+	body := got[:strings.Index(got, "... [truncated:")]
+	if estimateTokens(body) > 20 {
+		t.Errorf("Truncated body has %d tokens, want <= 20", estimateTokens(body))
+	}
+}
+
+// TestContentMaxBytes tests that oversized files are cut at a line boundary so that content and note fit the byte cap.
+func TestContentMaxBytes(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	small := "short file\n"
+	large := strings.Repeat("line 0001\n", 20) // 200 bytes
+	writeTestFiles(t, tempDir, map[string]string{"small.txt": small, "large.txt": large})
+
+	opts := Options{ExcludeList: map[string]bool{}, ContentMaxBytes: 100}
+	_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	if fileContents["small.txt"] != small {
+		t.Errorf("small.txt should be untouched, got %q", fileContents["small.txt"])
+	}
+	got := fileContents["large.txt"]
+	want := strings.Repeat("line 0001\n", 5) + "... [truncated: showing first 50 of 200 bytes]\n"
+	if got != want {
+		t.Errorf("large.txt = %q, want %q", got, want)
+	}
+	if len(got) > 100 {
+		t.Errorf("large.txt has %d bytes including the note, want <= 100", len(got))
+	}
+
+	// A cap too small for any line leaves only the note.
+	if got := truncateToBytes("a very long line\n", 5); got != "... [truncated: showing first 0 of 17 bytes]\n" {
+		t.Errorf("truncateToBytes() = %q", got)
+	}
+}
+
+// TestTokenCounts tests the per-file breakdown and its total.
+func TestTokenCounts(t *testing.T) {
+	fileContents := map[string]string{
+		filepath.FromSlash("a/small.go"): "abcd",
+		"big.go":                         strings.Repeat("x", 40),
+		"same.go":                        "wxyz",
+	}
+	var buf strings.Builder
+	printTokenCounts(&buf, fileContents)
+	expected := "Estimated tokens: 12 (3 files)\n" +
+		"        10  big.go\n" +
+		"         1  a/small.go\n" +
+		"         1  same.go\n"
+	if buf.String() != expected {
+		t.Errorf("printTokenCounts() =\n%s\nwant\n%s", buf.String(), expected)
+	}
+}
+
+// TestTokenBudget tests that files are added in order until the budget is exceeded.
+func TestTokenBudget(t *testing.T) {
+	fileContents := map[string]string{
+		"a.go": strings.Repeat("a", 20), // 5 tokens
+		"b.go": strings.Repeat("b", 20), // 5 tokens
+		"c.go": strings.Repeat("c", 40), // 10 tokens
+		"d.go": "d",                     // 1 token, after the budget is exceeded
+	}
+	skipped := applyTokenBudget(fileContents, []string{"a.go", "b.go", "c.go", "d.go"}, 12)
+
+	if strings.Join(skipped, ",") != "c.go,d.go" {
+		t.Errorf("skipped = %v, want [c.go d.go]", skipped)
+	}
+	if len(fileContents) != 2 || fileContents["a.go"] == "" || fileContents["b.go"] == "" {
+		t.Errorf("Unexpected remaining files: %v", fileContents)
+	}
+
+	// A budget that fits everything skips nothing.
+	fileContents = map[string]string{"a.go": "abc"}
+	if skipped := applyTokenBudget(fileContents, []string{"a.go"}, 1); skipped != nil || len(fileContents) != 1 {
+		t.Errorf("Nothing should be skipped, got %v", skipped)
+	}
+}
+
+// TestFileCountWarning tests that the warning fires above the threshold and not at or below it.
+func TestFileCountWarning(t *testing.T) {
+	var buf strings.Builder
+	printFileCountWarning(&buf, 100, 100)
+	if buf.Len() != 0 {
+		t.Errorf("No warning expected at the threshold, got %q", buf.String())
+	}
+	printFileCountWarning(&buf, 101, 100)
+	if !strings.HasPrefix(buf.String(), "WARNING: 101 files included (more than 100).") {
+		t.Errorf("Expected a warning above the threshold, got %q", buf.String())
+	}
+}
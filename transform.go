@@ -0,0 +1,352 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// expandTabs 将制表符展开为空格，按 width 列对齐制表位（与 expand(1) 相同）
+func expandTabs(text string, width int) string {
+	if width <= 0 || !strings.Contains(text, "\t") {
+		return text
+	}
+	var b strings.Builder
+	col := 0
+	for _, r := range text {
+		switch r {
+		case '\t':
+			n := width - col%width
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+		case '\n':
+			b.WriteRune(r)
+			col = 0
+		default:
+			b.WriteRune(r)
+			col++
+		}
+	}
+	return b.String()
+}
+
+// indentUnit 推测以空格缩进的文本的缩进单位：取相邻非空行之间最常见的缩进增量，
+// 无法判断时返回 0
+func indentUnit(lines []string) int {
+	counts := make(map[int]int)
+	prev := 0
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if strings.HasPrefix(line[indent:], "\t") {
+			continue
+		}
+		if indent > prev {
+			counts[indent-prev]++
+		}
+		prev = indent
+	}
+	unit, best := 0, 0
+	for delta, n := range counts {
+		if n > best || (n == best && delta < unit) {
+			unit, best = delta, n
+		}
+	}
+	return unit
+}
+
+// reindent 将每行行首的缩进统一为每层 width 个空格，保持相对结构。
+// 以制表符缩进的行每个制表符算一层；空格缩进按推测出的缩进单位换算，
+// 不足一层的余数原样保留（如续行对齐）。
+func reindent(text string, width int) string {
+	lines := strings.Split(text, "\n")
+	unit := indentUnit(lines)
+	for i, line := range lines {
+		tabs := len(line) - len(strings.TrimLeft(line, "\t"))
+		if tabs > 0 {
+			lines[i] = strings.Repeat(" ", tabs*width) + line[tabs:]
+			continue
+		}
+		spaces := len(line) - len(strings.TrimLeft(line, " "))
+		if spaces == 0 || unit == 0 || unit == width {
+			continue
+		}
+		levels, rest := spaces/unit, spaces%unit
+		lines[i] = strings.Repeat(" ", levels*width+rest) + line[spaces:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// defaultCodeExtensions 是 -strip-blank-lines-in-code 默认视为代码的扩展名，文档类文件不在其中
+const defaultCodeExtensions = ".go,.py,.js,.mjs,.jsx,.ts,.tsx,.java,.kt,.scala,.c,.h,.cc,.cpp,.hpp,.cs,.rs,.rb,.php,.swift,.sh,.bash,.lua,.sql,.css,.scss,.proto"
+
+// stripBlankLines 删除所有只含空白字符的行
+func stripBlankLines(text string) string {
+	lines := strings.SplitAfter(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "")
+}
+
+// replaceRule 是一条 -replace/-replace-regex 替换规则，count 累计替换次数
+type replaceRule struct {
+	old   string
+	new   string
+	re    *regexp.Regexp // -replace-regex 时非 nil，new 中可使用 $1 等引用
+	count atomic.Int64   // 文件并发读取，替换次数需要原子累计
+}
+
+// parseReplaceRule 解析 "old=new" 形式的规则，以第一个 = 分隔
+func parseReplaceRule(s string, isRegex bool) (*replaceRule, error) {
+	old, repl, ok := strings.Cut(s, "=")
+	if !ok || old == "" {
+		return nil, fmt.Errorf("invalid rule %q, want old=new", s)
+	}
+	rule := &replaceRule{old: old, new: repl}
+	if isRegex {
+		re, err := regexp.Compile(old)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern in rule %q: %w", s, err)
+		}
+		rule.re = re
+	}
+	return rule, nil
+}
+
+// apply 对文本执行替换并累计次数
+func (r *replaceRule) apply(text string) string {
+	if r.re == nil {
+		r.count.Add(int64(strings.Count(text, r.old)))
+		return strings.ReplaceAll(text, r.old, r.new)
+	}
+	r.count.Add(int64(len(r.re.FindAllStringIndex(text, -1))))
+	return r.re.ReplaceAllString(text, r.new)
+}
+
+// printReplaceCounts 输出每条替换规则的替换次数
+func printReplaceCounts(w io.Writer, rules []*replaceRule) {
+	fmt.Fprintln(w, "Replacements:")
+	for _, r := range rules {
+		kind := "replace"
+		if r.re != nil {
+			kind = "replace-regex"
+		}
+		fmt.Fprintf(w, "  %s %q -> %q: %d\n", kind, r.old, r.new, r.count.Load())
+	}
+}
+
+// headRule 描述 -head-glob 的一条规则：路径匹配 glob 的文件只保留前 lines 行
+type headRule struct {
+	re    *regexp.Regexp
+	lines int
+}
+
+// parseHeadRule 解析 "glob:N" 形式的规则，glob 的匹配方式与 gitignore 相同
+func parseHeadRule(s string) (headRule, error) {
+	glob, lines, err := splitGlobCount(s)
+	if err != nil {
+		return headRule{}, err
+	}
+	re, err := compileGlob(glob)
+	if err != nil {
+		return headRule{}, fmt.Errorf("invalid glob in rule %q: %w", s, err)
+	}
+	return headRule{re: re, lines: lines}, nil
+}
+
+// headLinesFor 返回第一条匹配 relPath 的规则的行数上限，没有匹配时返回 -1
+func headLinesFor(relPath string, rules []headRule) int {
+	for _, rule := range rules {
+		if rule.re.MatchString(filepath.ToSlash(relPath)) {
+			return rule.lines
+		}
+	}
+	return -1
+}
+
+// headLines 只保留文本的前 n 行，并注明被截掉的行数
+func headLines(text string, n int) string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= n {
+		return text
+	}
+	head := strings.Join(lines[:n], "")
+	if head != "" && !strings.HasSuffix(head, "\n") {
+		head += "\n"
+	}
+	return head + fmt.Sprintf("... [truncated: showing first %d of %d lines]\n", n, len(lines))
+}
+
+// tailLines 只保留文本的最后 n 行，并在开头注明被截掉的行数
+func tailLines(text string, n int) string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= n {
+		return text
+	}
+	return fmt.Sprintf("... [truncated: showing last %d of %d lines]\n", n, len(lines)) + strings.Join(lines[len(lines)-n:], "")
+}
+
+// lineNumberWidth 是行号的最小宽度
+const lineNumberWidth = 4
+
+// numberLines 在每行前加上右对齐的行号和 " | "，行号从 1 开始，宽度随行数增加；末尾没有换行的文件保持如此
+func numberLines(text string) string {
+	if text == "" {
+		return text
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	width := max(lineNumberWidth, len(strconv.Itoa(len(lines))))
+	var b strings.Builder
+	for i, line := range lines {
+		fmt.Fprintf(&b, "%*d | %s", width, i+1, line)
+	}
+	return b.String()
+}
+
+// transforms 是 -transform-glob 支持的变换，needsArg 表示是否需要 =N 参数
+var transforms = map[string]struct {
+	needsArg bool
+	apply    func(text string, n int) string
+}{
+	"head":              {true, headLines},
+	"tail":              {true, tailLines},
+	"expand-tabs":       {true, expandTabs},
+	"reindent":          {true, reindent},
+	"strip-blank-lines": {false, func(text string, _ int) string { return stripBlankLines(text) }},
+}
+
+// transformRule 是 -transform-glob 的一条规则：路径匹配 glob 的文件执行变换 name
+type transformRule struct {
+	re   *regexp.Regexp
+	name string
+	arg  int
+}
+
+// parseTransformRule 解析 "glob:name" 或 "glob:name=N" 形式的规则，如 "testdata/**:head=10"
+func parseTransformRule(s string) (transformRule, error) {
+	i := strings.LastIndex(s, ":")
+	if i <= 0 {
+		return transformRule{}, fmt.Errorf("invalid rule %q, want glob:transform[=N]", s)
+	}
+	glob, spec := s[:i], s[i+1:]
+	name, value, hasArg := strings.Cut(spec, "=")
+	t, ok := transforms[name]
+	if !ok {
+		return transformRule{}, fmt.Errorf("unknown transform %q in rule %q", name, s)
+	}
+	rule := transformRule{name: name}
+	if t.needsArg {
+		n, err := strconv.Atoi(value)
+		if !hasArg || err != nil || n < 0 {
+			return transformRule{}, fmt.Errorf("transform %s in rule %q needs a count, e.g. %s=10", name, s, name)
+		}
+		rule.arg = n
+	} else if hasArg {
+		return transformRule{}, fmt.Errorf("transform %s in rule %q takes no argument", name, s)
+	}
+	re, err := compileGlob(glob)
+	if err != nil {
+		return transformRule{}, fmt.Errorf("invalid glob in rule %q: %w", s, err)
+	}
+	rule.re = re
+	return rule, nil
+}
+
+// applyTransforms 按顺序对匹配 relPath 的规则执行变换
+func applyTransforms(relPath, text string, rules []transformRule) string {
+	for _, rule := range rules {
+		if rule.re.MatchString(filepath.ToSlash(relPath)) {
+			text = transforms[rule.name].apply(text, rule.arg)
+		}
+	}
+	return text
+}
+
+// transformStages 是 -transform-order 可以排列的内容变换，各阶段在未开启对应选项时原样返回文本
+var transformStages = map[string]func(relPath, ext, text string, opts Options) string{
+	"strip-license": func(_, _, text string, opts Options) string {
+		if opts.StripLicenseHeaders {
+			text = stripLicenseHeader(text, opts.LicenseSample)
+		}
+		return text
+	},
+	"replace": func(_, _, text string, opts Options) string {
+		for _, rule := range opts.Replacements {
+			text = rule.apply(text)
+		}
+		return text
+	},
+	"expand-tabs": func(_, _, text string, opts Options) string {
+		return expandTabs(text, opts.ExpandTabs)
+	},
+	"reindent": func(_, _, text string, opts Options) string {
+		if opts.Reindent > 0 {
+			text = reindent(text, opts.Reindent)
+		}
+		return text
+	},
+	"strip-blank-lines": func(_, ext, text string, opts Options) string {
+		if opts.StripBlankLines[strings.ToLower(ext)] {
+			text = stripBlankLines(text)
+		}
+		return text
+	},
+	"head": func(relPath, _, text string, opts Options) string {
+		if n := headLinesFor(relPath, opts.HeadRules); n >= 0 {
+			text = headLines(text, n)
+		}
+		return text
+	},
+	"transform-glob": func(relPath, _, text string, opts Options) string {
+		return applyTransforms(relPath, text, opts.Transforms)
+	},
+}
+
+// defaultTransformOrder 是内容变换的默认顺序：先删除许可证头再替换，使替换只作用于保留下来的内容；
+// 截取开头若干行放在空白行删除之后，使 head 计算的是实际输出的行
+var defaultTransformOrder = []string{"strip-license", "replace", "expand-tabs", "reindent", "strip-blank-lines", "head", "transform-glob"}
+
+// parseTransformOrder 解析逗号分隔的变换顺序，未列出的变换按默认顺序排在其后
+func parseTransformOrder(s string) ([]string, error) {
+	var order []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if transformStages[name] == nil {
+			return nil, fmt.Errorf("unknown transform %q (want one of %s)", name, strings.Join(defaultTransformOrder, ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("transform %q is listed twice", name)
+		}
+		seen[name] = true
+		order = append(order, name)
+	}
+	for _, name := range defaultTransformOrder {
+		if !seen[name] {
+			order = append(order, name)
+		}
+	}
+	return order, nil
+}
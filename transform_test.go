@@ -0,0 +1,317 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestExpandTabs tests tab expansion to tab stops.
+func TestExpandTabs(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  string
+	}{
+		{"leading tabs", "func f() {\n\treturn\n}\n", 4, "func f() {\n    return\n}\n"},
+		{"nested", "\t\tx", 2, "    x"},
+		{"aligned to tab stops", "ab\tc\nabcd\te", 4, "ab  c\nabcd    e"},
+		{"disabled", "\tx", 0, "\tx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandTabs(tt.text, tt.width); got != tt.want {
+				t.Errorf("expandTabs(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExpandTabsOption tests that -expand-tabs rewrites file contents but not the tree.
+func TestExpandTabsOption(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go": "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, ExpandTabs: 4}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if got := fileContents["main.go"]; !strings.Contains(got, "\n    println(\"hi\")\n") || strings.Contains(got, "\t") {
+		t.Errorf("Tabs not expanded:\n%q", got)
+	}
+	if !strings.Contains(tree, "main.go") {
+		t.Errorf("Tree is missing main.go:\n%s", tree)
+	}
+}
+
+// TestHeadGlob tests that only files matching a -head-glob rule are cut to their first lines.
+func TestHeadGlob(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	long := strings.Repeat("line\n", 10)
+	writeTestFiles(t, tempDir, map[string]string{
+		"db/schema.sql": long,
+		"short.sql":     "select 1;\n",
+		"main.go":       long,
+	})
+
+	rule, err := parseHeadRule("*.sql:3")
+	if err != nil {
+		t.Fatalf("parseHeadRule() returned error: %v", err)
+	}
+	opts := Options{ExcludeList: map[string]bool{}, HeadRules: []headRule{rule}}
+	_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	want := "line\nline\nline\n... [truncated: showing first 3 of 10 lines]\n"
+	if got := fileContents[filepath.Join("db", "schema.sql")]; got != want {
+		t.Errorf("db/schema.sql = %q, want %q", got, want)
+	}
+	if got := fileContents["short.sql"]; got != "select 1;\n" {
+		t.Errorf("short.sql should be unchanged, got %q", got)
+	}
+	if got := fileContents["main.go"]; got != long {
+		t.Errorf("main.go should be unchanged, got %q", got)
+	}
+}
+
+// TestReindent tests normalizing indentation while keeping relative structure.
+func TestReindent(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  string
+	}{
+		{
+			name:  "four to two spaces",
+			text:  "def f(x):\n    if x:\n        return 1\n\n    return 2\n",
+			width: 2,
+			want:  "def f(x):\n  if x:\n    return 1\n\n  return 2\n",
+		},
+		{
+			name:  "alignment remainder kept",
+			text:  "a:\n    b(1,\n      2)\n    c:\n        d\n",
+			width: 2,
+			want:  "a:\n  b(1,\n    2)\n  c:\n    d\n",
+		},
+		{
+			name:  "tabs",
+			text:  "func f() {\n\tif x {\n\t\ty()\n\t}\n}\n",
+			width: 2,
+			want:  "func f() {\n  if x {\n    y()\n  }\n}\n",
+		},
+		{
+			name:  "already at width",
+			text:  "a:\n  b\n",
+			width: 2,
+			want:  "a:\n  b\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reindent(tt.text, tt.width); got != tt.want {
+				t.Errorf("reindent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReplaceRules tests literal and regex replacements and their counts.
+func TestReplaceRules(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"config.yaml": "api: https://api.internal.corp/v1\nauth: https://auth.internal.corp\n",
+		"main.go":     "// AcmeCorp client, build 1234\npackage main\n",
+	})
+
+	literal, err := parseReplaceRule("internal.corp=example.com", false)
+	if err != nil {
+		t.Fatalf("parseReplaceRule() returned error: %v", err)
+	}
+	regex, err := parseReplaceRule(`build (\d+)=build <$1>`, true)
+	if err != nil {
+		t.Fatalf("parseReplaceRule() returned error: %v", err)
+	}
+	opts := Options{ExcludeList: map[string]bool{}, Replacements: []*replaceRule{literal, regex}}
+	_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	if got, want := fileContents["config.yaml"], "api: https://api.example.com/v1\nauth: https://auth.example.com\n"; got != want {
+		t.Errorf("config.yaml = %q, want %q", got, want)
+	}
+	if got, want := fileContents["main.go"], "// AcmeCorp client, build <1234>\npackage main\n"; got != want {
+		t.Errorf("main.go = %q, want %q", got, want)
+	}
+	if literal.count.Load() != 2 || regex.count.Load() != 1 {
+		t.Errorf("Counts = %d, %d; want 2, 1", literal.count.Load(), regex.count.Load())
+	}
+	if _, err := parseReplaceRule("no-separator", false); err == nil {
+		t.Error("parseReplaceRule() should reject a rule without '='")
+	}
+}
+
+// TestStripBlankLinesInCode tests removing blank lines from code files only.
+func TestStripBlankLinesInCode(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	readme := "# Title\n\nFirst paragraph.\n\nSecond paragraph.\n"
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":   "package main\n\nimport \"fmt\"\n\n  \t\nfunc main() {\n\n\tfmt.Println()\n}\n",
+		"README.md": readme,
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}, StripBlankLines: map[string]bool{".go": true}}
+	_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if got, want := fileContents["main.go"], "package main\nimport \"fmt\"\nfunc main() {\n\tfmt.Println()\n}\n"; got != want {
+		t.Errorf("main.go = %q, want %q", got, want)
+	}
+	if got := fileContents["README.md"]; got != readme {
+		t.Errorf("README.md should be untouched, got %q", got)
+	}
+}
+
+// TestTransformGlob tests applying a head transform only to files under a matching glob.
+func TestTransformGlob(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	long := "1\n2\n3\n4\n5\n"
+	writeTestFiles(t, tempDir, map[string]string{
+		"testdata/a/case.txt": long,
+		"logs/app.txt":        long,
+		"main.txt":            long,
+	})
+
+	var rules []transformRule
+	for _, s := range []string{"testdata/**:head=2", "logs/*.txt:tail=1"} {
+		rule, err := parseTransformRule(s)
+		if err != nil {
+			t.Fatalf("parseTransformRule(%q) returned error: %v", s, err)
+		}
+		rules = append(rules, rule)
+	}
+	opts := Options{ExcludeList: map[string]bool{}, Transforms: rules}
+	_, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	if got, want := fileContents[filepath.Join("testdata", "a", "case.txt")], "1\n2\n... [truncated: showing first 2 of 5 lines]\n"; got != want {
+		t.Errorf("testdata/a/case.txt = %q, want %q", got, want)
+	}
+	if got, want := fileContents[filepath.Join("logs", "app.txt")], "... [truncated: showing last 1 of 5 lines]\n5\n"; got != want {
+		t.Errorf("logs/app.txt = %q, want %q", got, want)
+	}
+	if got := fileContents["main.txt"]; got != long {
+		t.Errorf("main.txt should be unchanged, got %q", got)
+	}
+
+	for _, bad := range []string{"*.go", "*.go:shout", "*.go:head", "*.go:head=x", "*.go:strip-blank-lines=3"} {
+		if _, err := parseTransformRule(bad); err == nil {
+			t.Errorf("parseTransformRule(%q) should fail", bad)
+		}
+	}
+}
+
+// TestLineNumbers tests right-aligned line numbers, including files without a trailing newline.
+func TestLineNumbers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"a\nb\n", "   1 | a\n   2 | b\n"},
+		{"a\n\nb", "   1 | a\n   2 | \n   3 | b"},
+	}
+	for _, tt := range tests {
+		if got := numberLines(tt.input); got != tt.expected {
+			t.Errorf("numberLines(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+	// The width grows with the line count.
+	got := numberLines(strings.Repeat("x\n", 10000))
+	if !strings.HasPrefix(got, "    1 | x\n") || !strings.HasSuffix(got, "10000 | x\n") {
+		t.Errorf("Unexpected numbering for 10000 lines: %q...%q", got[:20], got[len(got)-20:])
+	}
+
+	// Numbering restarts per file in the txt output.
+	fileContents := map[string]string{"a.go": "package a\n", "b.go": "package b\nvar x = 1"}
+	var out strings.Builder
+	if err := writeOutput(&out, "", fileContents, Options{LineNumbers: true}); err != nil {
+		t.Fatalf("writeOutput() returned error: %v", err)
+	}
+	for _, want := range []string{"File: a.go\n   1 | package a\n", "File: b.go\n   1 | package b\n   2 | var x = 1\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Output is missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+// TestTransformOrder tests that -transform-order changes how transforms interact.
+func TestTransformOrder(t *testing.T) {
+	rule, err := parseHeadRule("*.go:2")
+	if err != nil {
+		t.Fatalf("parseHeadRule() returned error: %v", err)
+	}
+	text := "a\n\n\nb\nc\n"
+	opts := Options{HeadRules: []headRule{rule}, StripBlankLines: map[string]bool{".go": true}}
+
+	// By default blank lines are stripped first, so head keeps two lines of code.
+	want := "a\nb\n... [truncated: showing first 2 of 3 lines]\n"
+	if got := transformContent("x.go", ".go", text, opts); got != want {
+		t.Errorf("Default order = %q, want %q", got, want)
+	}
+
+	order, err := parseTransformOrder("head,strip-blank-lines")
+	if err != nil {
+		t.Fatalf("parseTransformOrder() returned error: %v", err)
+	}
+	if len(order) != len(defaultTransformOrder) || order[0] != "head" || order[1] != "strip-blank-lines" {
+		t.Errorf("Unlisted transforms should follow in the default order, got %v", order)
+	}
+	opts.TransformOrder = order
+	want = "a\n... [truncated: showing first 2 of 5 lines]\n"
+	if got := transformContent("x.go", ".go", text, opts); got != want {
+		t.Errorf("head before strip-blank-lines = %q, want %q", got, want)
+	}
+
+	for _, bad := range []string{"shout", "head,head"} {
+		if _, err := parseTransformOrder(bad); err == nil {
+			t.Errorf("parseTransformOrder(%q) should fail", bad)
+		}
+	}
+}
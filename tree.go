@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// treeNode 是目录树中的一个节点
+type treeNode struct {
+	name       string
+	relPath    string
+	isDir      bool
+	linkTarget string // 符号链接的目标，非链接为空
+	listOnly   bool   // 只出现在目录树中、没有收录内容的条目（如指向目录的符号链接）
+	size       int64  // 文件大小（字节）
+	binary     bool   // 被跳过的二进制文件，在目录树中标注 (binary, skipped)
+	truncated  bool   // 目录的子条目因 -depth-glob 的深度限制被省略
+	children   []*treeNode
+}
+
+// newTree 创建以 rootName 为根的目录树
+func newTree(rootName string) *treeNode {
+	return &treeNode{name: rootName, relPath: ".", isDir: true}
+}
+
+// addChild 在当前目录下挂一个子条目。WalkDir 按先序遍历，父目录总是先于子条目加入。
+func (n *treeNode) addChild(name, relPath string, isDir bool) *treeNode {
+	child := &treeNode{name: name, relPath: relPath, isDir: isDir}
+	n.children = append(n.children, child)
+	return child
+}
+
+// removeChild 从当前目录下移除一个子条目
+func (n *treeNode) removeChild(child *treeNode) {
+	for i, c := range n.children {
+		if c == child {
+			n.children = append(n.children[:i], n.children[i+1:]...)
+			return
+		}
+	}
+}
+
+// pruneTree 返回只保留匹配 re 的条目的目录树副本。匹配的目录保留整个子树，
+// 不匹配的目录只在还有匹配的后代时保留。根目录总是保留。
+func pruneTree(root *treeNode, re *regexp.Regexp) *treeNode {
+	pruned := *root
+	pruned.children = nil
+	for _, child := range root.children {
+		if c := pruneNode(child, re); c != nil {
+			pruned.children = append(pruned.children, c)
+		}
+	}
+	return &pruned
+}
+
+func pruneNode(node *treeNode, re *regexp.Regexp) *treeNode {
+	if re.MatchString(filepath.ToSlash(node.relPath)) {
+		return node
+	}
+	if !node.isDir {
+		return nil
+	}
+	pruned := pruneTree(node, re)
+	if len(pruned.children) == 0 {
+		return nil
+	}
+	return pruned
+}
+
+// renderTree 将目录树渲染为 tree 命令风格的文本，以 ├──、└── 和 │ 连接各条目
+func renderTree(root *treeNode, opts Options) string {
+	if opts.TreeOnlyGlob != nil {
+		root = pruneTree(root, opts.TreeOnlyGlob)
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s/%s\n", root.name, countSuffix(root, opts)))
+	renderChildren(&b, root, "", opts)
+	return b.String()
+}
+
+// 树形连接符：中间的条目、最后一个条目，以及它们的子条目所用的前缀
+const (
+	treeBranch     = "├── "
+	treeLastBranch = "└── "
+	treeLine       = "│   "
+	treeSpace      = "    "
+)
+
+// defaultTruncationMarker 是目录树中被截断的分支的默认标记，见 -truncation-marker
+const defaultTruncationMarker = "⋯ [truncated]"
+
+// truncationMarker 返回 opts 中的截断标记，未设置时使用默认标记
+func truncationMarker(opts Options) string {
+	if opts.TruncationMarker == "" {
+		return defaultTruncationMarker
+	}
+	return opts.TruncationMarker
+}
+
+// renderChildren 渲染目录的子条目，prefix 是上层目录留下的连接线。
+// 被截断的分支以截断标记作为最后一个条目：超过 TreeMaxEntries 的部分写作 "标记 (M more)"，
+// 因深度限制被省略的子条目只写标记。
+func renderChildren(b *strings.Builder, node *treeNode, prefix string, opts Options) {
+	children := node.children
+	if opts.TreeMaxEntries > 0 && len(children) > opts.TreeMaxEntries {
+		children = children[:opts.TreeMaxEntries]
+	}
+	hidden := len(node.children) - len(children)
+	for i, child := range children {
+		last := i == len(children)-1 && hidden == 0 && !node.truncated
+		renderNode(b, child, prefix, last, opts)
+	}
+	switch {
+	case hidden > 0:
+		b.WriteString(fmt.Sprintf("%s%s%s (%d more)\n", prefix, treeLastBranch, truncationMarker(opts), hidden))
+	case node.truncated:
+		b.WriteString(prefix + treeLastBranch + truncationMarker(opts) + "\n")
+	}
+}
+
+// renderNode 渲染一个条目，last 表示它是所在目录的最后一个条目
+func renderNode(b *strings.Builder, node *treeNode, prefix string, last bool, opts Options) {
+	indent, childPrefix := prefix+treeBranch, prefix+treeLine
+	if last {
+		indent, childPrefix = prefix+treeLastBranch, prefix+treeSpace
+	}
+	if !node.isDir {
+		if opts.SymlinkTargets && node.linkTarget != "" {
+			b.WriteString(fmt.Sprintf("%s%s -> %s\n", indent, node.name, node.linkTarget))
+			return
+		}
+		if node.binary {
+			b.WriteString(fmt.Sprintf("%s%s (binary, skipped)\n", indent, node.name))
+			return
+		}
+		b.WriteString(fmt.Sprintf("%s%s\n", indent, node.name))
+		return
+	}
+
+	name := node.name
+	// 将只有单个子目录的目录链折叠为一行，如 a/b/c/
+	if opts.CompactTree {
+		for len(node.children) == 1 && node.children[0].isDir {
+			node = node.children[0]
+			name += "/" + node.name
+		}
+	}
+	if opts.SymlinkTargets && node.linkTarget != "" {
+		b.WriteString(fmt.Sprintf("%s%s/ -> %s%s\n", indent, name, node.linkTarget, countSuffix(node, opts)))
+	} else {
+		b.WriteString(fmt.Sprintf("%s%s/%s\n", indent, name, countSuffix(node, opts)))
+	}
+	renderChildren(b, node, childPrefix, opts)
+}
+
+// fileCount 返回目录下（递归）收录的文件数
+func (n *treeNode) fileCount() int {
+	if !n.isDir {
+		if n.listOnly || n.binary {
+			return 0
+		}
+		return 1
+	}
+	count := 0
+	for _, child := range n.children {
+		count += child.fileCount()
+	}
+	return count
+}
+
+// countSuffix 在开启 TreeCounts 时返回形如 " (42 files)" 的后缀
+func countSuffix(n *treeNode, opts Options) string {
+	if !opts.TreeCounts {
+		return ""
+	}
+	count := n.fileCount()
+	if count == 1 {
+		return " (1 file)"
+	}
+	return fmt.Sprintf(" (%d files)", count)
+}
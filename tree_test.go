@@ -0,0 +1,301 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCompactTree tests that single-child directory chains are collapsed.
+func TestCompactTree(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for _, path := range []string{"a/b/c/d/file.txt", "x/y.txt", "x/z.txt"} {
+		fullPath := filepath.Join(tempDir, path)
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte(path), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	opts := Options{ExcludeList: map[string]bool{}}
+	tree, _, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if strings.Contains(tree, "a/b/c/d/") {
+		t.Errorf("Tree should not be compacted by default:\n%s", tree)
+	}
+
+	opts.CompactTree = true
+	tree, _, err = buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if !strings.Contains(tree, "\n├── a/b/c/d/\n│   └── file.txt\n") {
+		t.Errorf("Single-child chain was not collapsed:\n%s", tree)
+	}
+	if !strings.Contains(tree, "\n└── x/\n    ├── y.txt\n    └── z.txt\n") {
+		t.Errorf("Directory with several children should be kept as is:\n%s", tree)
+	}
+}
+
+// TestSymlinkTargets tests that symlinks are annotated with their targets.
+func TestSymlinkTargets(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	os.MkdirAll(filepath.Join(tempDir, "real"), 0755)
+	if err := os.WriteFile(filepath.Join(tempDir, "real", "file.txt"), []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.Symlink("real/file.txt", filepath.Join(tempDir, "link.txt")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	if err := os.Symlink("real", filepath.Join(tempDir, "linkdir")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	opts := Options{ExcludeList: map[string]bool{}, SymlinkTargets: true}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	for _, want := range []string{"link.txt -> real/file.txt\n", "linkdir -> real\n"} {
+		if !strings.Contains(tree, want) {
+			t.Errorf("Tree is missing %q:\n%s", want, tree)
+		}
+	}
+	if fileContents["link.txt"] != "content" {
+		t.Errorf("Symlinked file content = %q, want %q", fileContents["link.txt"], "content")
+	}
+	if _, ok := fileContents["linkdir"]; ok {
+		t.Error("Symlinked directory should not have content")
+	}
+}
+
+// TestFollowSymlinks tests walking into symlinked directories without looping on cycles.
+func TestFollowSymlinks(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	outside, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(outside)
+
+	writeTestFiles(t, tempDir, map[string]string{"main.go": "package main", "pkg/a.go": "package pkg"})
+	writeTestFiles(t, outside, map[string]string{"lib.go": "package lib", "sub/util.go": "package sub"})
+	links := map[string]string{
+		"vendored":  outside, // Vendored code outside the repository.
+		"vendored2": outside, // The same target is only walked once.
+		"loop":      ".",     // Points back to the root.
+		"pkg/up":    "..",    // Points to an ancestor.
+		"pkg/self":  ".",     // Points to its own directory.
+		"pkg/alias": "../pkg",
+		"docs":      "pkg", // Inside the repository, already walked as pkg.
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(tempDir, name)); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+	}
+
+	opts := Options{ExcludeList: map[string]bool{}, SymlinkTargets: true, FollowSymlinks: true, AllowOutside: true}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	for _, path := range []string{"main.go", "pkg/a.go", "vendored/lib.go", "vendored/sub/util.go"} {
+		if _, ok := fileContents[filepath.FromSlash(path)]; !ok {
+			t.Errorf("Expected file not found: %s", path)
+		}
+	}
+	if len(fileContents) != 4 {
+		t.Errorf("Got %d files, want 4: %v", len(fileContents), fileContents)
+	}
+	for _, want := range []string{"vendored/ -> " + outside, "vendored2 -> " + outside, "loop -> .", "up -> ..", "self -> .", "alias -> ../pkg", "docs -> pkg"} {
+		if !containsLine(tree, want) {
+			t.Errorf("Tree is missing %q:\n%s", want, tree)
+		}
+	}
+
+	// Without -allow-outside, a link resolving outside the repository is listed but not walked into.
+	opts.AllowOutside = false
+	tree, fileContents, err = buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if _, ok := fileContents[filepath.FromSlash("vendored/lib.go")]; ok {
+		t.Error("Symlinked directory outside the repository should be skipped without -allow-outside")
+	}
+	if !containsLine(tree, "vendored -> "+outside) {
+		t.Errorf("Skipped link should still be listed:\n%s", tree)
+	}
+
+	// By default symlinked directories are listed but not walked into.
+	opts.FollowSymlinks = false
+	_, fileContents, err = buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	if _, ok := fileContents[filepath.FromSlash("vendored/lib.go")]; ok {
+		t.Error("Symlinked directory should not be walked into by default")
+	}
+}
+
+// TestTreeCounts tests that directory nodes carry recursive counts of included files.
+func TestTreeCounts(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"src/a.go":       "package src",
+		"src/b.go":       "package src",
+		"src/image.png":  "not really a png",
+		"src/sub/c.go":   "package sub",
+		"docs/README.md": "# docs",
+		"top.go":         "package main",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{".png": true}, TreeCounts: true}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	want := []string{
+		filepath.Base(tempDir) + "/ (5 files)\n",
+		"docs/ (1 file)\n",
+		"src/ (3 files)\n",
+		"│   └── sub/ (1 file)\n",
+	}
+	for _, line := range want {
+		if !strings.Contains(tree, line) {
+			t.Errorf("Tree is missing %q:\n%s", line, tree)
+		}
+	}
+	if len(fileContents) != 5 {
+		t.Errorf("Got %d included files, want 5", len(fileContents))
+	}
+}
+
+// TestTreeMaxEntriesPerDir tests that wide directories are capped in the tree only.
+func TestTreeMaxEntriesPerDir(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{"top.txt": "top"}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		files[filepath.Join("wide", name+".txt")] = name
+	}
+	writeTestFiles(t, tempDir, files)
+
+	opts := Options{ExcludeList: map[string]bool{}, TreeMaxEntries: 3}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	want := "└── wide/\n    ├── a.txt\n    ├── b.txt\n    ├── c.txt\n    └── ⋯ [truncated] (4 more)\n"
+	if !strings.Contains(tree, want) {
+		t.Errorf("Tree is missing %q:\n%s", want, tree)
+	}
+	if strings.Contains(tree, "d.txt") {
+		t.Errorf("Tree should not list entries beyond the cap:\n%s", tree)
+	}
+	if len(fileContents) != 8 {
+		t.Errorf("Got %d files with content, want 8", len(fileContents))
+	}
+}
+
+// TestTreeOnlyGlob tests that -tree-only-glob prunes the tree without affecting content selection.
+func TestTreeOnlyGlob(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":             "package main",
+		"README.md":           "# readme",
+		"cmd/tool/tool.go":    "package main",
+		"docs/guide.md":       "guide",
+		"internal/x/x.go":     "package x",
+		"internal/x/x_doc.md": "doc",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{}}
+	opts.TreeOnlyGlob, err = compileGlob("*.go")
+	if err != nil {
+		t.Fatalf("compileGlob() returned error: %v", err)
+	}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+
+	for _, line := range []string{"main.go", "cmd/", "tool/", "tool.go", "internal/", "x/", "x.go"} {
+		if !containsLine(tree, line) {
+			t.Errorf("Tree is missing %q:\n%s", line, tree)
+		}
+	}
+	for _, line := range []string{"README.md", "docs/", "guide.md", "x_doc.md"} {
+		if containsLine(tree, line) {
+			t.Errorf("Tree should not contain %q:\n%s", line, tree)
+		}
+	}
+	if len(fileContents) != 6 {
+		t.Errorf("Got %d files with content, want all 6", len(fileContents))
+	}
+}
+
+// containsLine reports whether any line of s, with indentation and tree connectors trimmed, equals line.
+func containsLine(s, line string) bool {
+	for _, l := range strings.Split(s, "\n") {
+		if strings.TrimLeft(l, " │├└─") == line {
+			return true
+		}
+	}
+	return false
+}
+
+// TestRenderTreeGolden tests the connector layout of the rendered tree against testdata/tree.golden.
+func TestRenderTreeGolden(t *testing.T) {
+	root := newTree("repo")
+	cmd := root.addChild("cmd", "cmd", true)
+	tool := cmd.addChild("tool", "cmd/tool", true)
+	tool.addChild("main.go", "cmd/tool/main.go", false)
+	tool.addChild("main_test.go", "cmd/tool/main_test.go", false)
+	root.addChild("docs", "docs", true).addChild("guide.md", "docs/guide.md", false)
+	internal := root.addChild("internal", "internal", true)
+	internal.addChild("parse", "internal/parse", true).addChild("parse.go", "internal/parse/parse.go", false)
+	internal.addChild("render", "internal/render", true)
+	root.addChild("go.mod", "go.mod", false)
+
+	want, err := os.ReadFile(filepath.Join("testdata", "tree.golden"))
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if got := renderTree(root, Options{}); got != string(want) {
+		t.Errorf("renderTree() =\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// treeJSONSchema 标记 tree-json 输出，使之后的运行不会把它当作源文件收录
+const treeJSONSchema = schemaPrefix + "tree-json/v1"
+
+// jsonTreeNode 是 tree-json 格式中的一个节点，只有根节点带 $schema
+type jsonTreeNode struct {
+	Schema   string          `json:"$schema,omitempty"`
+	Name     string          `json:"name"`
+	Type     string          `json:"type"`           // dir、file 或 symlink
+	Size     *int64          `json:"size,omitempty"` // 文件大小；开启 -json-tree-rollup 时目录为其下所有文件的总大小
+	Target   string          `json:"target,omitempty"`
+	Children []*jsonTreeNode `json:"children,omitempty"`
+}
+
+// toJSONTree 转换目录树，rollup 时在构建过程中为每个目录累计其子条目的大小
+func toJSONTree(n *treeNode, rollup bool) *jsonTreeNode {
+	node := &jsonTreeNode{Name: n.name}
+	switch {
+	case n.isDir:
+		node.Type = "dir"
+		var total int64
+		for _, child := range n.children {
+			c := toJSONTree(child, rollup)
+			if c.Size != nil {
+				total += *c.Size
+			}
+			node.Children = append(node.Children, c)
+		}
+		if rollup {
+			node.Size = &total
+		}
+	case n.listOnly:
+		node.Type = "symlink"
+		node.Target = n.linkTarget
+	default:
+		node.Type = "file"
+		size := n.size
+		node.Size = &size
+		node.Target = n.linkTarget
+	}
+	return node
+}
+
+// writeTreeJSON 只输出嵌套的目录树 JSON（含文件大小，不含内容），rollup 时目录也带有总大小
+func writeTreeJSON(out io.Writer, tree *treeNode, rollup bool) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	root := toJSONTree(tree, rollup)
+	root.Schema = treeJSONSchema
+	return enc.Encode(root)
+}
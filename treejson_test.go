@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestTreeJSON tests that the tree-json format has sizes, respects filters and has no contents.
+func TestTreeJSON(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":       "package main\n",
+		"pkg/util.go":   "package pkg // util\n",
+		"pkg/image.png": "png",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{".png": true}, Format: "tree-json"}
+	var buf bytes.Buffer
+	if err := writeDirectoryStructure(tempDir, opts, &buf); err != nil {
+		t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+	}
+	if strings.Contains(buf.String(), "content") || strings.Contains(buf.String(), "package") {
+		t.Errorf("Tree JSON should not include contents:\n%s", buf.String())
+	}
+
+	var root jsonTreeNode
+	if err := json.Unmarshal(buf.Bytes(), &root); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, buf.String())
+	}
+	if root.Type != "dir" || len(root.Children) != 2 {
+		t.Fatalf("Unexpected root: %+v", root)
+	}
+	mainGo, pkg := root.Children[0], root.Children[1]
+	if mainGo.Name != "main.go" || mainGo.Type != "file" || mainGo.Size == nil || *mainGo.Size != 13 {
+		t.Errorf("Unexpected main.go node: %+v", mainGo)
+	}
+	if pkg.Name != "pkg" || pkg.Type != "dir" || pkg.Size != nil || len(pkg.Children) != 1 {
+		t.Fatalf("Unexpected pkg node: %+v", pkg)
+	}
+	if util := pkg.Children[0]; util.Name != "util.go" || util.Size == nil || *util.Size != 20 {
+		t.Errorf("Unexpected util.go node: %+v", util)
+	}
+}
+
+// TestSkipOwnOutput tests that earlier tree-json, json, yaml and xml dumps in the tree are recognized and not ingested.
+func TestSkipOwnOutput(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":      "package main\n",
+		"package.json": "{\n  \"name\": \"demo\"\n}\n",
+	})
+	dumps := map[string]string{}
+	for format, name := range map[string]string{"tree-json": "old-tree.json", "json": "old-dump.json", "yaml": "old-dump.yaml", "xml": "old-dump.xml"} {
+		var dump bytes.Buffer
+		if err := writeDirectoryStructure(tempDir, Options{ExcludeList: map[string]bool{}, Format: format, ShowTree: true}, &dump); err != nil {
+			t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+		}
+		dumps["snapshots/"+name] = dump.String()
+	}
+	writeTestFiles(t, tempDir, dumps)
+
+	opts := Options{ExcludeList: map[string]bool{}, Excluded: &exclusionLog{}}
+	tree, fileContents, err := buildDirectoryStructure(tempDir, opts)
+	if err != nil {
+		t.Fatalf("buildDirectoryStructure() returned error: %v", err)
+	}
+	for relPath := range fileContents {
+		if strings.Contains(relPath, "old-") {
+			t.Errorf("Prior output should be skipped: %s", relPath)
+		}
+	}
+	if _, ok := fileContents["package.json"]; !ok {
+		t.Error("Ordinary JSON files should still be included")
+	}
+	if strings.Contains(tree, "old-") {
+		t.Errorf("Prior output should not appear in the tree:\n%s", tree)
+	}
+	if len(opts.Excluded.entries) != len(dumps) {
+		t.Errorf("Excluded entries = %v, want %d own-output entries", opts.Excluded.entries, len(dumps))
+	}
+	for _, e := range opts.Excluded.entries {
+		if e.Reason != reasonOwnOutput {
+			t.Errorf("Excluded entry %v, want reason %s", e, reasonOwnOutput)
+		}
+	}
+}
+
+// TestTreeJSONRollup tests that with rollup each directory's size is the sum of its children's.
+func TestTreeJSONRollup(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	writeTestFiles(t, tempDir, map[string]string{
+		"main.go":          "package main\n",
+		"pkg/util.go":      "package pkg // util\n",
+		"pkg/sub/a.go":     "package sub\n",
+		"pkg/sub/b.go":     "package sub // b\n",
+		"pkg/empty/x.skip": "skipped",
+	})
+
+	opts := Options{ExcludeList: map[string]bool{".skip": true}, Format: "tree-json", TreeRollup: true}
+	var buf bytes.Buffer
+	if err := writeDirectoryStructure(tempDir, opts, &buf); err != nil {
+		t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+	}
+	var root jsonTreeNode
+	if err := json.Unmarshal(buf.Bytes(), &root); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, buf.String())
+	}
+
+	var check func(n *jsonTreeNode) int64
+	check = func(n *jsonTreeNode) int64 {
+		if n.Size == nil {
+			t.Fatalf("Node %s has no size", n.Name)
+		}
+		if n.Type != "dir" {
+			return *n.Size
+		}
+		var sum int64
+		for _, c := range n.Children {
+			sum += check(c)
+		}
+		if *n.Size != sum {
+			t.Errorf("Directory %s has size %d, want the sum of its children %d", n.Name, *n.Size, sum)
+		}
+		return *n.Size
+	}
+	if total := check(&root); total != 13+20+12+17 {
+		t.Errorf("Root size = %d, want %d", total, 13+20+12+17)
+	}
+
+	// Without rollup directories carry no size.
+	buf.Reset()
+	opts.TreeRollup = false
+	if err := writeDirectoryStructure(tempDir, opts, &buf); err != nil {
+		t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+	}
+	var plain jsonTreeNode
+	if err := json.Unmarshal(buf.Bytes(), &plain); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if plain.Size != nil {
+		t.Errorf("Directories should have no size without rollup, got %d", *plain.Size)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+)
+
+// 版本信息，发布构建时通过 -ldflags 注入，如
+// go build -ldflags "-X main.version=v1.2.0 -X main.commit=$(git rev-parse --short HEAD) -X main.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version   = ""
+	commit    = ""
+	buildDate = ""
+)
+
+// buildVersion 返回版本、提交和构建时间。未通过 -ldflags 注入的字段从 debug.ReadBuildInfo 中读取
+// （go install 记录的模块版本，以及 go build 记录的 vcs.revision 和 vcs.time），仍然未知时为 "unknown"。
+func buildVersion() (ver, rev, date string) {
+	ver, rev, date = version, commit, buildDate
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if ver == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			ver = info.Main.Version
+		}
+		for _, s := range info.Settings {
+			switch {
+			case s.Key == "vcs.revision" && rev == "":
+				rev = s.Value
+			case s.Key == "vcs.time" && date == "":
+				date = s.Value
+			}
+		}
+	}
+	if ver == "" {
+		ver = "devel"
+	}
+	if rev == "" {
+		rev = "unknown"
+	}
+	if date == "" {
+		date = "unknown"
+	}
+	return ver, rev, date
+}
+
+// printVersion 输出 -version 的信息
+func printVersion(w io.Writer) {
+	ver, rev, date := buildVersion()
+	fmt.Fprintf(w, "local-gitingest %s (commit %s, built %s)\n", ver, rev, date)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestPrintVersion tests that values injected with -ldflags take precedence over the build info.
+func TestPrintVersion(t *testing.T) {
+	defer func(v, c, d string) { version, commit, buildDate = v, c, d }(version, commit, buildDate)
+	version, commit, buildDate = "v1.2.3", "abc1234", "2026-01-02T03:04:05Z"
+
+	var out strings.Builder
+	printVersion(&out)
+	if want := "local-gitingest v1.2.3 (commit abc1234, built 2026-01-02T03:04:05Z)\n"; out.String() != want {
+		t.Errorf("printVersion() = %q, want %q", out.String(), want)
+	}
+
+	// Without injected values every field still has a value.
+	version, commit, buildDate = "", "", ""
+	ver, rev, date := buildVersion()
+	if ver == "" || rev == "" || date == "" {
+		t.Errorf("buildVersion() = %q, %q, %q, want no empty fields", ver, rev, date)
+	}
+}
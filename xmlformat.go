@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// xmlEscaper 转义文本中的 XML 特殊字符。与 xml.EscapeText 不同，换行和制表符保持原样，内容更便于阅读。
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// escapeXML 转义 XML 特殊字符，并把 XML 1.0 中不允许出现的字符（如 NUL 和非法的 UTF-8 字节）替换为 U+FFFD
+func escapeXML(s string) string {
+	return xmlEscaper.Replace(strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || !isXMLChar(r) {
+			return utf8.RuneError
+		}
+		return r
+	}, s))
+}
+
+// isXMLChar 判断字符是否在 XML 1.0 的 Char 范围内
+func isXMLChar(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' ||
+		r >= 0x20 && r <= 0xD7FF ||
+		r >= 0xE000 && r <= 0xFFFD ||
+		r >= 0x10000 && r <= 0x10FFFF
+}
+
+// xmlHeader 是 xml 输出的开头，isOwnOutput 据此和第一个 <document> 识别本工具先前的输出
+const xmlHeader = "<documents>\n"
+
+// writeXML 以 <documents><document index="1"><source>路径</source><document_contents>内容</document_contents></document></documents>
+// 的形式输出文件，顺序与其他格式相同
+func writeXML(out io.Writer, fileContents map[string]string, opts Options) error {
+	io.WriteString(out, xmlHeader)
+	for i, relPath := range outputOrder(fileContents, opts) {
+		// 内容原样放在标签之间，不额外添加换行，解析后与原内容完全一致
+		fmt.Fprintf(out, "<document index=\"%d\">\n<source>%s</source>\n<document_contents>%s</document_contents>\n</document>\n",
+			i+1, escapeXML(filepath.ToSlash(relPath)), escapeXML(fileContents[relPath]))
+	}
+	_, err := io.WriteString(out, "</documents>\n")
+	return err
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"testing"
+)
+
+// TestXMLFormat tests that the xml output is well-formed and round-trips paths and contents.
+func TestXMLFormat(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "local-gitingest-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"main.go":           "package main\n\nfunc main() {\n\tif a < b && c > d {\n\t}\n}\n",
+		"docs/a&b <x>.html": "<p class=\"x\">Tom & 'Jerry'</p>",
+		"cdata.txt":         "]]> <![CDATA[ not really ]]>\n",
+	}
+	writeTestFiles(t, tempDir, files)
+
+	opts := Options{ExcludeList: map[string]bool{}, Format: "xml"}
+	var buf bytes.Buffer
+	if err := writeDirectoryStructure(tempDir, opts, &buf); err != nil {
+		t.Fatalf("writeDirectoryStructure() returned error: %v", err)
+	}
+
+	var docs struct {
+		XMLName   xml.Name `xml:"documents"`
+		Documents []struct {
+			Index    int    `xml:"index,attr"`
+			Source   string `xml:"source"`
+			Contents string `xml:"document_contents"`
+		} `xml:"document"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &docs); err != nil {
+		t.Fatalf("Output is not well-formed XML: %v\n%s", err, buf.String())
+	}
+	wantOrder := []string{"cdata.txt", "docs/a&b <x>.html", "main.go"}
+	if len(docs.Documents) != len(wantOrder) {
+		t.Fatalf("Got %d documents, want %d:\n%s", len(docs.Documents), len(wantOrder), buf.String())
+	}
+	for i, doc := range docs.Documents {
+		if doc.Index != i+1 || doc.Source != wantOrder[i] {
+			t.Errorf("Document %d = (%d, %q), want (%d, %q)", i, doc.Index, doc.Source, i+1, wantOrder[i])
+		}
+		if doc.Contents != files[doc.Source] {
+			t.Errorf("Contents of %s = %q, want %q", doc.Source, doc.Contents, files[doc.Source])
+		}
+	}
+	if !isOwnOutput(buf.Bytes()) {
+		t.Error("XML output should be recognized as own output")
+	}
+}
+
+// TestEscapeXML tests that characters not allowed in XML are replaced.
+func TestEscapeXML(t *testing.T) {
+	if got := escapeXML("a\x00b\x1b<\xff>\t\n"); got != "a\uFFFDb\uFFFD&lt;\uFFFD&gt;\t\n" {
+		t.Errorf("escapeXML() = %q", got)
+	}
+}